@@ -7,7 +7,6 @@ import (
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/hasjo/MLBLG/pkg"
 )
@@ -27,9 +26,8 @@ func buildReplaceMap() map[string]string{
 }
 
 
-func getObjects(ctx context.Context, config aws.Config, bucketname string) {
+func getObjects(ctx context.Context, svc *s3.Client, bucketname string) {
 	replaceMap := buildReplaceMap()
-	svc := s3.NewFromConfig(config)
 	objects, err := svc.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
 		Bucket: aws.String(bucketname),
 	})
@@ -64,7 +62,7 @@ func getObjects(ctx context.Context, config aws.Config, bucketname string) {
 
 func main() {
 	ctx := context.Background()
-	sdkConfig, err := config.LoadDefaultConfig(ctx)
+	storage, err := pkg.NewS3Storage(ctx, pkg.S3ConfigFromEnv(BucketName))
 	if err != nil { log.Fatal(err) }
-	getObjects(ctx, sdkConfig, BucketName)
+	getObjects(ctx, storage.Client, storage.Bucket)
 }
@@ -1,151 +1,108 @@
 package main
 
 import (
-	"bytes"
 	"context"
-	"fmt"
-	"io"
+	"errors"
 	"log"
 	"os"
 	"path/filepath"
-	"strings"
-	"text/template"
+	"time"
 
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
-	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/hasjo/MLBLG/pkg"
 )
 
 const BucketName = "scorecards.jjhsk.com"
 
-type TemplateData struct {
-	Title string
-	Contents string
-}
-
-
-func pushFiletoS3(bucketname string, keyname string, buf bytes.Buffer, contentType string){
-	ctx := context.Background()
-	sdkConfig, err := config.LoadDefaultConfig(ctx)
-	svc := s3.NewFromConfig(sdkConfig)
+func runLambda(ctx context.Context) error {
+	storage, err := pkg.NewS3Storage(ctx, pkg.S3ConfigFromEnv(BucketName))
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	bufReader := bytes.NewReader(buf.Bytes())
-	putObjInput := &s3.PutObjectInput{
-			Bucket: aws.String(bucketname),
-			Key: aws.String(keyname),
-			Body: io.ReadSeeker(bufReader),
-	}
-	if contentType != "" {
-		putObjInput.ContentType = aws.String(contentType)
+	config := pkg.ConfigData{
+		WatchTeams:       pkg.AllTeams,
+		ReportPath:       "",
+		ReceiptPath:      filepath.Join("", "receipts"),
+		PagePath:         filepath.Join("", "page"),
+		SiteURL:          os.Getenv("SITE_URL"),
+		Webhooks:         pkg.WebhooksFromEnv(),
+		ChatWebhooks:     pkg.ChatWebhooksFromEnv(),
+		SMTP:             pkg.SMTPConfigFromEnv(),
+		EmailSubscribers: pkg.EmailSubscribersFromEnv(),
 	}
-	svc.PutObject(
-		ctx,
-		putObjInput,
-	)
-}
-
-func checkObject(bucketname string, keyname string) bool{
-	ctx := context.Background()
-	sdkConfig, err := config.LoadDefaultConfig(ctx)
-	svc := s3.NewFromConfig(sdkConfig)
-	_, err = svc.HeadObject(
-		ctx, 
-		&s3.HeadObjectInput{
-			Bucket: aws.String(bucketname),
-			Key: aws.String(keyname),
-		},
-	)
+	// The plan in the bucket says which games are due, so most invocations
+	// only read it and go back to sleep
+	plan, err := pkg.LoadSchedulePlan(ctx, storage)
 	if err != nil {
-		log.Println(err)
-		return false
+		return err
 	}
-	return true
-}
-
-func generateListPage(dirname string, title string) bytes.Buffer{
-	ctx := context.Background()
-	sdkConfig, err := config.LoadDefaultConfig(ctx)
-	svc := s3.NewFromConfig(sdkConfig)
-	var dataSlice []types.Object
-	listdata, err := svc.ListObjectsV2(
-		ctx,
-		&s3.ListObjectsV2Input{
-			Bucket: aws.String(BucketName),
-			Prefix: aws.String(dirname),
-		})
-	if err != nil {
-		log.Fatal(err)
+	newDay := plan.Date != pkg.TodayDate()
+	plan, ok := pkg.RefreshSchedulePlan(ctx, plan, config)
+	if !ok {
+		log.Printf("No schedule for %s yet", pkg.TodayDate())
+		return nil
 	}
-	dataSlice = append(dataSlice, listdata.Contents...)
-	for *listdata.IsTruncated {
-		listdata, err = svc.ListObjectsV2(
-			ctx,
-			&s3.ListObjectsV2Input{
-				Bucket: aws.String(BucketName),
-				ContinuationToken: aws.String(*listdata.NextContinuationToken),
-				Prefix: aws.String(dirname),
-			})
-		dataSlice = append(dataSlice, listdata.Contents...)
+	var publishErrors []error
+	var publishedKeys []string
+	var indexKeys []string
+	var publishedGames []int
+	now := time.Now()
+	if wake := plan.NextWake(); !newDay && (wake.IsZero() || wake.After(now)) {
+		return nil
 	}
-	var contentstring string
-	for _, thing := range(dataSlice){
-		keyname := strings.ReplaceAll(*thing.Key, dirname, "")
-		contentstring = fmt.Sprintf("<a href=\"%s%s\">%s</a></br>\n",dirname, keyname, keyname) + contentstring
+	data := pkg.RunDueChecks(ctx, &plan, now, false, nil)
+	publishedReports := make([][]string, len(data))
+	for ind, report := range data {
+		log.Printf("Found %s - Live: %t", report.Filename, report.Live)
+		published, err := pkg.PublishReport(ctx, storage, report, config)
+		for _, key := range published {
+			log.Printf("Pushed %s to s3", key)
+		}
+		publishedReports[ind] = published
+		publishedKeys = append(publishedKeys, published...)
+		if err != nil {
+			log.Printf("Failed to push %s to s3: %s", report.Filename, err)
+			publishErrors = append(publishErrors, err)
+		} else {
+			indexKeys = append(indexKeys, pkg.ReportKeys(report, config)...)
+			publishedGames = append(publishedGames, report.GamePk)
+		}
 	}
-	file, err := os.ReadFile("list.html")
+	// Games are only done once the manifest knows their cards, otherwise
+	// the next run looks at them again and fills the manifest in
+	err = pkg.PublishIndexPages(ctx, storage, indexKeys, config)
 	if err != nil {
-		log.Fatal(err)
+		log.Printf("Failed to update the index pages: %s", err)
+		publishErrors = append(publishErrors, err)
+	} else {
+		for _, gamePk := range publishedGames {
+			plan.MarkDone(gamePk)
+		}
 	}
-	filestring := string(file)
-	tmpl, err := template.New("test").Parse(filestring)
+	err = pkg.SaveSchedulePlan(ctx, storage, plan)
 	if err != nil {
-		log.Fatal(err)
+		log.Printf("Failed to save the schedule plan: %s", err)
+		publishErrors = append(publishErrors, err)
 	}
-	data := TemplateData{title, contentstring}
-	var retBuff bytes.Buffer
-	err = tmpl.Execute(&retBuff, data)
-	return retBuff
-}
-
-func runLambda(){
-	config := pkg.ConfigData{
-		WatchTeams: pkg.AllTeams,
-		ReportPath: "",
-		ReceiptPath: filepath.Join("", "receipts"),
-		PagePath: filepath.Join("", "page"),
+	if newDay || len(publishedKeys) > 0 {
+		err = pkg.PublishCalendars(ctx, storage, pkg.FindUpcomingGames(ctx, config, pkg.BaseLinksURL), config)
+		if err != nil {
+			log.Printf("Failed to update the calendars: %s", err)
+			publishErrors = append(publishErrors, err)
+		}
 	}
-	data := pkg.GenerateFullReport(config, false)
-	for _, report := range data{
-		log.Printf("Found %s - Live: %t,  Monitoring...", report.Filename, report.Live)
-		if report.Live == true {
-			receiptpath := filepath.Join("receipt", report.Filename)
-			pagepath := filepath.Join("page", report.Filename)
-			receiptData := pkg.GenerateReceiptPDF(report.ReceiptData, config)
-			pageData := pkg.GeneratePagePDF(report.PageData, config)
-
-			if checkObject(BucketName, receiptpath) == false {
-				pushFiletoS3(BucketName, receiptpath, receiptData, "")
-				log.Printf("Pushing %s to s3", receiptpath)
-			} else {
-				log.Printf("Report exists in s3: %s", receiptpath)
-			}
-			if checkObject(BucketName, pagepath) == false {
-				pushFiletoS3(BucketName, pagepath, pageData, "")
-				log.Printf("Pushing %s to s3", pagepath)
-			} else {
-				log.Printf("Report exists in s3: %s", pagepath)
-			}
+	// Receivers go last so a slow one can't keep the plan and manifest
+	// from being saved. One being down isn't a publish failure, the
+	// delivery log already has the details
+	for ind, report := range data {
+		err = pkg.NotifyPublished(ctx, report, publishedReports[ind], config)
+		if err != nil {
+			log.Printf("Failed to notify webhooks about %s: %s", report.Filename, err)
 		}
 	}
-	pagePage := generateListPage("page/", "PAGES")
-	pushFiletoS3(BucketName, "page.html", pagePage, "text/html")
-	receiptPage := generateListPage("receipt/", "RECEIPTS")
-	pushFiletoS3(BucketName, "receipt.html", receiptPage, "text/html")
+	// A non-nil error marks the invocation as failed so the Lambda error
+	// metrics and alarms pick it up.
+	return errors.Join(publishErrors...)
 }
 
 func main() {
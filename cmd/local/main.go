@@ -1,7 +1,15 @@
 package main
 
-import "github.com/hasjo/MLBLG/pkg"
+import (
+	"os"
+
+	"github.com/hasjo/MLBLG/pkg"
+)
 
 func main(){
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		pkg.RunServe(os.Args[2:])
+		return
+	}
 	pkg.RunLocal()
 }
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/hasjo/MLBLG/pkg"
+)
+
+func main() {
+	config := pkg.GetOrHandleConfiguration()
+	reportsPtr := flag.String("reports", config.ReportPath, "Directory holding the page/ and receipt/ report archive")
+	outPtr := flag.String("out", "public", "Directory the site is written to")
+	siteURLPtr := flag.String("site-url", config.SiteURL, "Public URL of the site, used for absolute feed links")
+	flag.Parse()
+	config.SiteURL = *siteURLPtr
+	archive := pkg.NewFileStorage(*reportsPtr)
+	out := pkg.NewFileStorage(*outPtr)
+	if *reportsPtr == *outPtr {
+		out = archive
+	}
+	err := pkg.BuildSite(context.Background(), archive, out, config)
+	if err != nil {
+		log.Fatal("Failed to build the site: ", err)
+	}
+	log.Println("Wrote the site to", *outPtr)
+}
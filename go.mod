@@ -9,6 +9,7 @@ require (
 	github.com/aws/aws-sdk-go-v2 v1.36.5
 	github.com/aws/aws-sdk-go-v2/config v1.29.17
 	github.com/aws/aws-sdk-go-v2/service/s3 v1.83.0
+	github.com/boombuler/barcode v1.0.1
 	github.com/kirsle/configdir v0.0.0-20170128060238-e45d2f54772f
 	golang.org/x/exp v0.0.0-20250506013437-ce4c2cf36ca6
 )
@@ -0,0 +1,177 @@
+package pkg
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+const APIPrefix = "/v1"
+
+// How long clients may cache each endpoint. Lineups and the schedule move
+// around before first pitch, standings and the archive hardly at all.
+const (
+	apiGamesMaxAge     = 60
+	apiLineupMaxAge    = 60
+	apiStandingsMaxAge = 300
+	apiReportsMaxAge   = 30
+)
+
+// writeAPIJSON encodes value with a strong ETag taken from the tagged value
+// and answers 304 when the client already has it. tagged is usually value
+// itself, but lets timestamps like generatedAt stay out of the tag.
+func writeAPIJSON(writer http.ResponseWriter, request *http.Request, value any, tagged any, maxAge int) {
+	body, err := json.MarshalIndent(value, "", "    ")
+	if err != nil {
+		log.Println("Failed to encode api response, error:", err)
+		writeAPIError(writer, http.StatusInternalServerError, "failed to encode the response")
+		return
+	}
+	tagBody, err := json.Marshal(tagged)
+	if err != nil {
+		tagBody = body
+	}
+	etag := fmt.Sprintf(`"%x"`, sha256.Sum256(tagBody))
+	writer.Header().Set("ETag", etag)
+	writer.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", maxAge))
+	for _, match := range strings.Split(request.Header.Get("If-None-Match"), ",") {
+		if strings.TrimSpace(match) == etag || strings.TrimSpace(match) == "*" {
+			writer.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Write(append(body, '\n'))
+}
+
+func writeAPIError(writer http.ResponseWriter, status int, message string) {
+	body, _ := json.Marshal(APIError{Error: message})
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Set("Cache-Control", "no-store")
+	writer.WriteHeader(status)
+	writer.Write(append(body, '\n'))
+}
+
+// GET /v1/games?date=YYYY-MM-DD&team=Minnesota+Twins
+func apiGames(config ConfigData) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		date := request.URL.Query().Get("date")
+		if date == "" {
+			date = TodayDate()
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			writeAPIError(writer, http.StatusBadRequest, "date must look like 2025-08-11")
+			return
+		}
+		// Every team unless one was asked for
+		scheduleConfig := config
+		scheduleConfig.WatchTeams = AllTeams
+		if team := request.URL.Query().Get("team"); team != "" {
+			if !slices.Contains(AllTeams, team) {
+				writeAPIError(writer, http.StatusBadRequest, "unknown team "+team)
+				return
+			}
+			scheduleConfig.WatchTeams = []string{team}
+		}
+		scheduled, err := findScheduledGames(request.Context(), scheduleConfig, BaseLinksURL, date, date)
+		if err != nil {
+			log.Println(err)
+			writeAPIError(writer, http.StatusBadGateway, "couldn't look up the schedule")
+			return
+		}
+		games := []APIGame{}
+		for _, game := range scheduled {
+			games = append(games, APIGame{
+				GamePk:    game.GamePk,
+				Date:      game.OfficialDate,
+				StartTime: game.GameDate,
+				Away:      game.Teams.Away.Team.Name,
+				Home:      game.Teams.Home.Team.Name,
+				Venue:     game.Venue.Name,
+				Status:    game.Status.DetailedState,
+				Lineup:    fmt.Sprintf("%s/games/%d/lineup", APIPrefix, game.GamePk),
+			})
+		}
+		writeAPIJSON(writer, request, games, games, apiGamesMaxAge)
+	}
+}
+
+// GET /v1/games/{pk}/lineup
+func apiLineup(debug bool) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		gamePk, err := strconv.Atoi(request.PathValue("pk"))
+		if err != nil || gamePk <= 0 {
+			writeAPIError(writer, http.StatusBadRequest, "bad game pk")
+			return
+		}
+		report := GenerateGameReport(request.Context(), gamePk, debug)
+		if !report.OK {
+			writeAPIError(writer, http.StatusBadGateway, "couldn't look up the game")
+			return
+		}
+		if !report.Live {
+			writeAPIError(writer, http.StatusNotFound, "lineups aren't out yet")
+			return
+		}
+		lineup := BuildLineupReport(report)
+		tagged := lineup
+		tagged.GeneratedAt = ""
+		writeAPIJSON(writer, request, lineup, tagged, apiLineupMaxAge)
+	}
+}
+
+// GET /v1/standings
+func apiStandings(writer http.ResponseWriter, request *http.Request) {
+	standings := GenerateStandings(request.Context())
+	if !standings.OK {
+		writeAPIError(writer, http.StatusBadGateway, "couldn't look up the standings")
+		return
+	}
+	lineupStandings := BuildLineupStandings(standings)
+	writeAPIJSON(writer, request, lineupStandings, lineupStandings, apiStandingsMaxAge)
+}
+
+// GET /v1/reports lists the published cards in ReportPath, newest first,
+// with links into /files/.
+func apiReports(config ConfigData) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		manifest, err := LoadManifest(context.Background(), NewFileStorage(config.ReportPath))
+		if err != nil {
+			log.Println("Failed to load the report manifest, error:", err)
+			writeAPIError(writer, http.StatusInternalServerError, "failed to list the reports")
+			return
+		}
+		reports := []APIReport{}
+		for _, game := range CollectPublishedGames(ManifestKeys(manifest)) {
+			addReport := APIReport{
+				GamePk: game.GamePk,
+				Date:   game.Date,
+				Away:   game.Away,
+				Home:   game.Home,
+			}
+			for _, link := range game.Links {
+				addReport.Links = append(addReport.Links, APIReportLink{
+					Format: strings.ToLower(link.Label),
+					Href:   "/files/" + link.Href,
+				})
+			}
+			reports = append(reports, addReport)
+		}
+		writeAPIJSON(writer, request, reports, reports, apiReportsMaxAge)
+	}
+}
+
+func registerAPI(mux *http.ServeMux, config ConfigData, debug bool) {
+	mux.HandleFunc("GET "+APIPrefix+"/games", apiGames(config))
+	mux.HandleFunc("GET "+APIPrefix+"/games/{pk}/lineup", apiLineup(debug))
+	mux.HandleFunc("GET "+APIPrefix+"/standings", apiStandings)
+	mux.HandleFunc("GET "+APIPrefix+"/reports", apiReports(config))
+}
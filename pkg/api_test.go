@@ -0,0 +1,96 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testSchedule = `{"dates": [{"date": "2025-08-11", "games": [
+	{"gamePk": 776543, "gameDate": "2025-08-11T23:10:00Z", "officialDate": "2025-08-11",
+	 "status": {"detailedState": "Scheduled"},
+	 "teams": {"away": {"team": {"name": "Minnesota Twins"}}, "home": {"team": {"name": "Detroit Tigers"}}}},
+	{"gamePk": 776544, "gameDate": "2025-08-11T23:05:00Z", "officialDate": "2025-08-11",
+	 "status": {"detailedState": "Scheduled"},
+	 "teams": {"away": {"team": {"name": "New York Mets"}}, "home": {"team": {"name": "Philadelphia Phillies"}}}}
+]}]}`
+
+// Answers every statsapi request with body, or fails them all when ok is
+// false, until the test is done.
+func stubURLBody(t *testing.T, body string, ok bool) {
+	original := GetURLBody
+	GetURLBody = func(ctx context.Context, targetURL string) ([]byte, bool) {
+		if !ok {
+			return []byte{}, false
+		}
+		return []byte(body), true
+	}
+	t.Cleanup(func() { GetURLBody = original })
+}
+
+func TestAPIGames(t *testing.T) {
+	stubURLBody(t, testSchedule, true)
+	mux := NewServeMux(ConfigData{}, false)
+
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest("GET", APIPrefix+"/games?date=2025-08-11&team=New+York+Mets", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", recorder.Code, recorder.Body)
+	}
+	var games []APIGame
+	err := json.Unmarshal(recorder.Body.Bytes(), &games)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 1 || games[0].GamePk != 776544 || games[0].Home != "Philadelphia Phillies" {
+		t.Errorf("games %+v", games)
+	}
+	etag := recorder.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("no ETag")
+	}
+
+	request := httptest.NewRequest("GET", APIPrefix+"/games?date=2025-08-11&team=New+York+Mets", nil)
+	request.Header.Set("If-None-Match", etag)
+	recorder = httptest.NewRecorder()
+	mux.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusNotModified {
+		t.Errorf("status %d with a matching ETag, want 304", recorder.Code)
+	}
+}
+
+func TestAPIGamesUpstreamFailure(t *testing.T) {
+	for name, stub := range map[string]struct {
+		body string
+		ok   bool
+	}{
+		"fetch failed": {"", false},
+		"not json":     {"<html>502 Bad Gateway</html>", true},
+	} {
+		stubURLBody(t, stub.body, stub.ok)
+		recorder := httptest.NewRecorder()
+		NewServeMux(ConfigData{}, false).ServeHTTP(recorder, httptest.NewRequest("GET", APIPrefix+"/games?date=2025-08-11", nil))
+		if recorder.Code != http.StatusBadGateway {
+			t.Errorf("%s: status %d, want 502", name, recorder.Code)
+		}
+		if cache := recorder.Header().Get("Cache-Control"); cache != "no-store" {
+			t.Errorf("%s: Cache-Control %q", name, cache)
+		}
+		if recorder.Header().Get("ETag") != "" || strings.Contains(recorder.Body.String(), "[]") {
+			t.Errorf("%s: answered like an empty schedule", name)
+		}
+	}
+}
+
+func TestAPIGamesBadRequest(t *testing.T) {
+	for _, query := range []string{"date=yesterday", "team=Brooklyn+Dodgers"} {
+		recorder := httptest.NewRecorder()
+		NewServeMux(ConfigData{}, false).ServeHTTP(recorder, httptest.NewRequest("GET", APIPrefix+"/games?"+query, nil))
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", query, recorder.Code)
+		}
+	}
+}
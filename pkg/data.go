@@ -34,3 +34,38 @@ var AllTeams = []string{
 	"Colorado Rockies",
 	"Los Angeles Dodgers",
 }
+
+// Keyed by the statsapi team id, colors are the primary and secondary
+// club colors used for the page pdf header bands.
+var TeamMetadata = map[int]TeamInfo{
+	108: {Id: 108, Name: "Los Angeles Angels", Abbreviation: "LAA", PrimaryColor: "#BA0021", SecondaryColor: "#003263"},
+	109: {Id: 109, Name: "Arizona Diamondbacks", Abbreviation: "AZ", PrimaryColor: "#A71930", SecondaryColor: "#E3D4AD"},
+	110: {Id: 110, Name: "Baltimore Orioles", Abbreviation: "BAL", PrimaryColor: "#DF4601", SecondaryColor: "#000000"},
+	111: {Id: 111, Name: "Boston Red Sox", Abbreviation: "BOS", PrimaryColor: "#BD3039", SecondaryColor: "#0C2340"},
+	112: {Id: 112, Name: "Chicago Cubs", Abbreviation: "CHC", PrimaryColor: "#0E3386", SecondaryColor: "#CC3433"},
+	113: {Id: 113, Name: "Cincinnati Reds", Abbreviation: "CIN", PrimaryColor: "#C6011F", SecondaryColor: "#000000"},
+	114: {Id: 114, Name: "Cleveland Guardians", Abbreviation: "CLE", PrimaryColor: "#00385D", SecondaryColor: "#E50022"},
+	115: {Id: 115, Name: "Colorado Rockies", Abbreviation: "COL", PrimaryColor: "#333366", SecondaryColor: "#C4CED4"},
+	116: {Id: 116, Name: "Detroit Tigers", Abbreviation: "DET", PrimaryColor: "#0C2340", SecondaryColor: "#FA4616"},
+	117: {Id: 117, Name: "Houston Astros", Abbreviation: "HOU", PrimaryColor: "#002D62", SecondaryColor: "#EB6E1F"},
+	118: {Id: 118, Name: "Kansas City Royals", Abbreviation: "KC", PrimaryColor: "#004687", SecondaryColor: "#BD9B60"},
+	119: {Id: 119, Name: "Los Angeles Dodgers", Abbreviation: "LAD", PrimaryColor: "#005A9C", SecondaryColor: "#EF3E42"},
+	120: {Id: 120, Name: "Washington Nationals", Abbreviation: "WSH", PrimaryColor: "#AB0003", SecondaryColor: "#14225A"},
+	121: {Id: 121, Name: "New York Mets", Abbreviation: "NYM", PrimaryColor: "#002D72", SecondaryColor: "#FF5910"},
+	133: {Id: 133, Name: "Athletics", Abbreviation: "ATH", PrimaryColor: "#003831", SecondaryColor: "#EFB21E"},
+	134: {Id: 134, Name: "Pittsburgh Pirates", Abbreviation: "PIT", PrimaryColor: "#27251F", SecondaryColor: "#FDB827"},
+	135: {Id: 135, Name: "San Diego Padres", Abbreviation: "SD", PrimaryColor: "#2F241D", SecondaryColor: "#FFC425"},
+	136: {Id: 136, Name: "Seattle Mariners", Abbreviation: "SEA", PrimaryColor: "#0C2C56", SecondaryColor: "#005C5C"},
+	137: {Id: 137, Name: "San Francisco Giants", Abbreviation: "SF", PrimaryColor: "#FD5A1E", SecondaryColor: "#27251F"},
+	138: {Id: 138, Name: "St. Louis Cardinals", Abbreviation: "STL", PrimaryColor: "#C41E3A", SecondaryColor: "#0C2340"},
+	139: {Id: 139, Name: "Tampa Bay Rays", Abbreviation: "TB", PrimaryColor: "#092C5C", SecondaryColor: "#8FBCE6"},
+	140: {Id: 140, Name: "Texas Rangers", Abbreviation: "TEX", PrimaryColor: "#003278", SecondaryColor: "#C0111F"},
+	141: {Id: 141, Name: "Toronto Blue Jays", Abbreviation: "TOR", PrimaryColor: "#134A8E", SecondaryColor: "#1D2D5C"},
+	142: {Id: 142, Name: "Minnesota Twins", Abbreviation: "MIN", PrimaryColor: "#002B5C", SecondaryColor: "#D31145"},
+	143: {Id: 143, Name: "Philadelphia Phillies", Abbreviation: "PHI", PrimaryColor: "#E81828", SecondaryColor: "#002D72"},
+	144: {Id: 144, Name: "Atlanta Braves", Abbreviation: "ATL", PrimaryColor: "#CE1141", SecondaryColor: "#13274F"},
+	145: {Id: 145, Name: "Chicago White Sox", Abbreviation: "CWS", PrimaryColor: "#27251F", SecondaryColor: "#C4CED4"},
+	146: {Id: 146, Name: "Miami Marlins", Abbreviation: "MIA", PrimaryColor: "#00A3E0", SecondaryColor: "#EF3340"},
+	147: {Id: 147, Name: "New York Yankees", Abbreviation: "NYY", PrimaryColor: "#0C2340", SecondaryColor: "#C4CED3"},
+	158: {Id: 158, Name: "Milwaukee Brewers", Abbreviation: "MIL", PrimaryColor: "#12284B", SecondaryColor: "#FFC52F"},
+}
@@ -0,0 +1,247 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+const (
+	defaultSMTPPort = 587
+	// How long one message may take, connecting included
+	smtpTimeout = 30 * time.Second
+)
+
+// Reads the mail server from SMTP_HOST, SMTP_PORT, SMTP_USERNAME,
+// SMTP_PASSWORD and SMTP_FROM.
+func SMTPConfigFromEnv() SMTPConfig {
+	port, _ := strconv.Atoi(os.Getenv("SMTP_PORT"))
+	return SMTPConfig{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     port,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+}
+
+// Reads EMAIL_SUBSCRIBERS, a JSON object of team name to addresses.
+func EmailSubscribersFromEnv() map[string][]string {
+	subscribers := make(map[string][]string)
+	value := os.Getenv("EMAIL_SUBSCRIBERS")
+	if value == "" {
+		return subscribers
+	}
+	err := json.Unmarshal([]byte(value), &subscribers)
+	if err != nil {
+		log.Println("Failed to parse EMAIL_SUBSCRIBERS, error:", err)
+	}
+	return subscribers
+}
+
+// Everyone subscribed to either team, each address once.
+func emailRecipients(away string, home string, config ConfigData) []string {
+	var recipients []string
+	for _, team := range []string{away, home} {
+		for _, address := range config.EmailSubscribers[team] {
+			if !slices.Contains(recipients, address) {
+				recipients = append(recipients, address)
+			}
+		}
+	}
+	sort.Strings(recipients)
+	return recipients
+}
+
+// Wraps base64 at 76 characters like RFC 2045 asks.
+func writeBase64Lines(buffer *bytes.Buffer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		buffer.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buffer.WriteString(encoded + "\r\n")
+}
+
+type emailAttachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Renders the receipt and page PDFs that go out with every email of a card.
+func cardAttachments(report ReportData, config ConfigData) []emailAttachment {
+	receipt := GenerateReceiptPDF(report, config)
+	page := GeneratePagePDF(report, config)
+	name := strings.TrimSuffix(report.Filename, path.Ext(report.Filename))
+	return []emailAttachment{
+		{name + "-receipt.pdf", "application/pdf", receipt.Bytes()},
+		{name + "-page.pdf", "application/pdf", page.Bytes()},
+	}
+}
+
+// BuildCardEmail writes a multipart/mixed message with the plain text
+// lineup as the body and the receipt and page PDFs attached.
+func BuildCardEmail(report ReportData, from string, to string, config ConfigData) ([]byte, error) {
+	return buildCardEmail(report, from, to, GenerateLineupText(report, config), cardAttachments(report, config))
+}
+
+func buildCardEmail(report ReportData, from string, to string, text bytes.Buffer, attachments []emailAttachment) ([]byte, error) {
+	var message bytes.Buffer
+	writer := multipart.NewWriter(&message)
+	game := report.GameData
+	subject := fmt.Sprintf("Lineups: %s @ %s - %s",
+		game.Teams.Away.Name, game.Teams.Home.Name, game.Datetime.OfficialDate)
+	fmt.Fprintf(&message, "From: %s\r\n", from)
+	fmt.Fprintf(&message, "To: %s\r\n", to)
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&message, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	message.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&message, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", writer.Boundary())
+
+	part, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var encoded bytes.Buffer
+	writeBase64Lines(&encoded, text.Bytes())
+	part.Write(encoded.Bytes())
+
+	for _, attachment := range attachments {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", attachment.Filename)},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoded.Reset()
+		writeBase64Lines(&encoded, attachment.Data)
+		part.Write(encoded.Bytes())
+	}
+	err = writer.Close()
+	return message.Bytes(), err
+}
+
+// sendMail does what smtp.SendMail does, but over a connection tied to ctx
+// and bounded by smtpTimeout so a stalled server can't hold up the run.
+func sendMail(ctx context.Context, address string, host string, auth smtp.Auth, from string, to string, message []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	// Closing the connection unblocks whatever the client is waiting on
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		err = client.StartTLS(&tls.Config{ServerName: host})
+		if err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("server doesn't support AUTH")
+		}
+		err = client.Auth(auth)
+		if err != nil {
+			return err
+		}
+	}
+	err = client.Mail(from)
+	if err != nil {
+		return err
+	}
+	err = client.Rcpt(to)
+	if err != nil {
+		return err
+	}
+	data, err := client.Data()
+	if err != nil {
+		return err
+	}
+	_, err = data.Write(message)
+	if err != nil {
+		return err
+	}
+	err = data.Close()
+	if err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// EmailPublished mails a freshly generated card to the subscribers of both
+// teams, one message per address so nobody sees the rest of the list.
+func EmailPublished(ctx context.Context, report ReportData, published []string, config ConfigData) error {
+	smtpConfig := config.SMTP
+	if smtpConfig.Host == "" || !slices.Contains(published, PageKey(report.Filename)) {
+		return nil
+	}
+	recipients := emailRecipients(report.GameData.Teams.Away.Name, report.GameData.Teams.Home.Name, config)
+	if len(recipients) == 0 {
+		return nil
+	}
+	port := smtpConfig.Port
+	if port == 0 {
+		port = defaultSMTPPort
+	}
+	address := net.JoinHostPort(smtpConfig.Host, strconv.Itoa(port))
+	var auth smtp.Auth
+	if smtpConfig.Username != "" {
+		auth = smtp.PlainAuth("", smtpConfig.Username, smtpConfig.Password, smtpConfig.Host)
+	}
+	// The same card goes to everyone, so it's only rendered once
+	text := GenerateLineupText(report, config)
+	attachments := cardAttachments(report, config)
+	var sendErrors []error
+	for _, recipient := range recipients {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		message, err := buildCardEmail(report, smtpConfig.From, recipient, text, attachments)
+		if err == nil {
+			// Upgrades to STARTTLS whenever the server offers it
+			err = sendMail(ctx, address, smtpConfig.Host, auth, smtpConfig.From, recipient, message)
+		}
+		if err != nil {
+			sendErrors = append(sendErrors, fmt.Errorf("email to %s: %w", recipient, err))
+			continue
+		}
+		log.Println("Emailed", report.Filename, "to", recipient)
+	}
+	return errors.Join(sendErrors...)
+}
@@ -0,0 +1,261 @@
+package pkg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Reads a message and checks it's the text lineup plus the two PDFs.
+func checkCardEmail(t *testing.T, report ReportData, data []byte) {
+	t.Helper()
+	message, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	if err != nil || subject != "Lineups: Minnesota Twins @ Detroit Tigers - 2025-08-11" {
+		t.Errorf("subject %q, %v", subject, err)
+	}
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("content type %q, %v", mediaType, err)
+	}
+	reader := multipart.NewReader(message.Body, params["boundary"])
+	var parts []*multipart.Part
+	var bodies [][]byte
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if encoding := part.Header.Get("Content-Transfer-Encoding"); encoding != "base64" {
+			t.Errorf("part encoded as %q", encoding)
+		}
+		body, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, part))
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, part)
+		bodies = append(bodies, body)
+	}
+	if len(parts) != 3 {
+		t.Fatalf("got %d parts, want 3", len(parts))
+	}
+	if contentType := parts[0].Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain") {
+		t.Errorf("body is %s", contentType)
+	}
+	text := GenerateLineupText(report, ConfigData{})
+	if !bytes.Equal(bodies[0], text.Bytes()) {
+		t.Errorf("body is %q, want the text lineup", bodies[0])
+	}
+	name := strings.TrimSuffix(report.Filename, ".pdf")
+	for ind, filename := range []string{name + "-receipt.pdf", name + "-page.pdf"} {
+		part := parts[ind+1]
+		if part.FileName() != filename {
+			t.Errorf("attachment %d is %q, want %q", ind, part.FileName(), filename)
+		}
+		if part.Header.Get("Content-Type") != "application/pdf" || !bytes.HasPrefix(bodies[ind+1], []byte("%PDF-")) {
+			t.Errorf("attachment %s isn't a pdf", filename)
+		}
+	}
+}
+
+func TestBuildCardEmail(t *testing.T) {
+	report := testReport(776543, "Minnesota Twins", "Detroit Tigers")
+	data, err := BuildCardEmail(report, "cards@example.com", "scorer@example.com", ConfigData{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCardEmail(t, report, data)
+}
+
+func TestEmailRecipients(t *testing.T) {
+	config := ConfigData{EmailSubscribers: map[string][]string{
+		"Minnesota Twins": {"b@example.com", "a@example.com"},
+		"Detroit Tigers":  {"a@example.com", "c@example.com"},
+		"New York Mets":   {"d@example.com"},
+	}}
+	got := emailRecipients("Minnesota Twins", "Detroit Tigers", config)
+	want := []string{"a@example.com", "b@example.com", "c@example.com"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("recipients %v, want %v", got, want)
+	}
+}
+
+func listenerHostPort(t *testing.T, listener net.Listener) (string, int) {
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNumber, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return host, portNumber
+}
+
+type smtpStandIn struct {
+	listener net.Listener
+	mutex    sync.Mutex
+	messages map[string][]byte
+}
+
+// Speaks just enough SMTP to take messages without TLS or auth.
+func newSMTPStandIn(t *testing.T) *smtpStandIn {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &smtpStandIn{listener: listener, messages: make(map[string][]byte)}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (server *smtpStandIn) serve(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { fmt.Fprintf(conn, "%s\r\n", line) }
+	reply("220 stand-in ESMTP")
+	var recipient string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 stand-in")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			recipient = strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>")
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 go ahead")
+			var message bytes.Buffer
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				message.WriteString(strings.TrimPrefix(dataLine, "."))
+			}
+			server.mutex.Lock()
+			server.messages[recipient] = message.Bytes()
+			server.mutex.Unlock()
+			reply("250 queued")
+		case command == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestEmailPublished(t *testing.T) {
+	server := newSMTPStandIn(t)
+	host, smtpPort := listenerHostPort(t, server.listener)
+	report := testReport(776543, "Minnesota Twins", "Detroit Tigers")
+	config := ConfigData{
+		SMTP: SMTPConfig{Host: host, Port: smtpPort, From: "cards@example.com"},
+		EmailSubscribers: map[string][]string{
+			"Minnesota Twins": {"twins@example.com"},
+			"Detroit Tigers":  {"tigers@example.com"},
+		},
+	}
+
+	err := EmailPublished(context.Background(), report, []string{ReceiptKey(report.Filename)}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.mutex.Lock()
+	sent := len(server.messages)
+	server.mutex.Unlock()
+	if sent != 0 {
+		t.Fatal("emailed a card whose page wasn't published")
+	}
+
+	err = EmailPublished(context.Background(), report, []string{PageKey(report.Filename)}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	for _, recipient := range []string{"twins@example.com", "tigers@example.com"} {
+		message, ok := server.messages[recipient]
+		if !ok {
+			t.Errorf("nothing sent to %s", recipient)
+			continue
+		}
+		checkCardEmail(t, report, message)
+	}
+}
+
+func TestEmailPublishedStalledServer(t *testing.T) {
+	// Accepts connections but never says hello
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		var conns []net.Conn
+		defer func() {
+			for _, conn := range conns {
+				conn.Close()
+			}
+		}()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conns = append(conns, conn)
+		}
+	}()
+	host, smtpPort := listenerHostPort(t, listener)
+	report := testReport(776543, "Minnesota Twins", "Detroit Tigers")
+	config := ConfigData{
+		SMTP:             SMTPConfig{Host: host, Port: smtpPort, From: "cards@example.com"},
+		EmailSubscribers: map[string][]string{"Minnesota Twins": {"twins@example.com"}},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = EmailPublished(ctx, report, []string{PageKey(report.Filename)}, config)
+	if err == nil {
+		t.Fatal("sending to a stalled server succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("took %s to give up on a stalled server", elapsed)
+	}
+}
@@ -0,0 +1,112 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// The manifest lists every published report so the index pages can be
+// rebuilt without listing the whole bucket.
+const (
+	ManifestKey     = "manifest.json"
+	ManifestVersion = 1
+)
+
+// Builds the first manifest from a listing of the report prefixes, which
+// is only needed once for a site that predates the manifest.
+func bootstrapManifest(ctx context.Context, storage Storage) (Manifest, error) {
+	manifest := Manifest{Version: ManifestVersion}
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, prefix := range []string{PagePrefix + "/", ReceiptPrefix + "/"} {
+		keys, err := storage.List(ctx, prefix)
+		if err != nil {
+			return manifest, err
+		}
+		for _, key := range keys {
+			manifest.Reports = append(manifest.Reports, ManifestEntry{Key: key, Published: now})
+		}
+	}
+	return manifest, nil
+}
+
+func LoadManifest(ctx context.Context, storage Storage) (Manifest, error) {
+	var manifest Manifest
+	data, err := storage.Get(ctx, ManifestKey)
+	if errors.Is(err, ErrNotFound) {
+		return bootstrapManifest(ctx, storage)
+	}
+	if err != nil {
+		return manifest, err
+	}
+	err = json.Unmarshal(data, &manifest)
+	return manifest, err
+}
+
+func ManifestKeys(manifest Manifest) []string {
+	var keys []string
+	for _, entry := range manifest.Reports {
+		keys = append(keys, entry.Key)
+	}
+	return keys
+}
+
+// Maps each report key to the time it was first published.
+func ManifestPublished(manifest Manifest) map[string]string {
+	published := make(map[string]string)
+	for _, entry := range manifest.Reports {
+		published[entry.Key] = entry.Published
+	}
+	return published
+}
+
+// Adds keys the manifest doesn't know about yet as published at the given
+// time.
+func addToManifest(manifest Manifest, keys []string, published string) Manifest {
+	known := make(map[string]bool)
+	for _, entry := range manifest.Reports {
+		known[entry.Key] = true
+	}
+	for _, key := range keys {
+		if !known[key] {
+			known[key] = true
+			manifest.Reports = append(manifest.Reports, ManifestEntry{Key: key, Published: published})
+		}
+	}
+	manifest.Version = ManifestVersion
+	manifest.Updated = published
+	return manifest
+}
+
+func SaveManifest(ctx context.Context, storage Storage, manifest Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "    ")
+	if err != nil {
+		return err
+	}
+	return storage.Put(ctx, ManifestKey, data, "application/json")
+}
+
+// RecordPublished adds the keys the stored manifest doesn't list yet and
+// returns the updated manifest. It's only written, and changed is only
+// true, when a key was missing or the manifest was just bootstrapped.
+func RecordPublished(ctx context.Context, storage Storage, keys []string) (Manifest, bool, error) {
+	manifest, err := LoadManifest(ctx, storage)
+	if err != nil {
+		return manifest, false, err
+	}
+	known := ManifestPublished(manifest)
+	var missing []string
+	for _, key := range keys {
+		if _, ok := known[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	// A bootstrapped manifest has never been saved, so it has no Updated
+	if len(missing) == 0 && manifest.Updated != "" {
+		return manifest, false, nil
+	}
+	manifest = addToManifest(manifest, missing, time.Now().UTC().Format(time.RFC3339))
+	err = SaveManifest(ctx, storage, manifest)
+	return manifest, true, err
+}
@@ -0,0 +1,122 @@
+package pkg
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"codeberg.org/go-pdf/fpdf"
+)
+
+const (
+	bannerHeight = 28.0
+	stripeHeight = 4.0
+	logoSize     = 22.0
+)
+
+func parseHexColor(hex string) (int, int, int) {
+	var r, g, b int
+	_, err := fmt.Sscanf(hex, "#%02x%02x%02x", &r, &g, &b)
+	if err != nil {
+		return 0, 0, 0
+	}
+	return r, g, b
+}
+
+func downloadTeamLogo(teamId int, config ConfigData) string {
+	logoURL := strings.ReplaceAll(config.TeamLogoURL, "{id}", strconv.Itoa(teamId))
+	extension := strings.ToLower(path.Ext(logoURL))
+	if extension != ".png" && extension != ".jpg" {
+		extension = ".png"
+	}
+	resp, err := fetchClient.Get(logoURL)
+	if err != nil {
+		log.Println("Unable to download team logo", logoURL, "error:", err)
+		return ""
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		log.Println("Unable to download team logo", logoURL, "status:", resp.Status)
+		return ""
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Println("There was a problem reading the team logo", logoURL, "error:", err)
+		return ""
+	}
+	err = os.MkdirAll(config.TeamLogoDir, 0750)
+	if err != nil {
+		log.Println("Failed to create logo dir", config.TeamLogoDir, "error:", err)
+		return ""
+	}
+	logoPath := filepath.Join(config.TeamLogoDir, strconv.Itoa(teamId)+extension)
+	err = os.WriteFile(logoPath, body, 0640)
+	if err != nil {
+		log.Println("Failed to cache team logo", logoPath, "error:", err)
+		return ""
+	}
+	return logoPath
+}
+
+// Returns the path to a cached logo for the team, fetching it first when a
+// download URL is configured. An empty string means no logo is available.
+func FindTeamLogo(teamId int, config ConfigData) string {
+	if config.TeamLogoDir == "" {
+		return ""
+	}
+	for _, extension := range []string{".png", ".jpg"} {
+		logoPath := filepath.Join(config.TeamLogoDir, strconv.Itoa(teamId)+extension)
+		if _, err := os.Stat(logoPath); err == nil {
+			return logoPath
+		}
+	}
+	if config.TeamLogoURL == "" {
+		return ""
+	}
+	return downloadTeamLogo(teamId, config)
+}
+
+func drawTeamBand(pdf *fpdf.Fpdf, team Team, x float64, y float64, width float64, config ConfigData) {
+	info, ok := TeamMetadata[team.Id]
+	if !ok {
+		info = TeamInfo{Id: team.Id, Name: team.Name, PrimaryColor: "#000000", SecondaryColor: "#777777"}
+	}
+	pdf.SetFillColor(parseHexColor(info.PrimaryColor))
+	pdf.Rect(x, y, width, bannerHeight, "F")
+	pdf.SetFillColor(parseHexColor(info.SecondaryColor))
+	pdf.Rect(x, y+bannerHeight, width, stripeHeight, "F")
+	textX := x + 6
+	if logoPath := FindTeamLogo(team.Id, config); logoPath != "" {
+		imageOptions := fpdf.ImageOptions{ReadDpi: false}
+		pdf.ImageOptions(logoPath, x+3, y+(bannerHeight-logoSize)/2, logoSize, logoSize, false, imageOptions, 0, "")
+		if pdf.Ok() {
+			textX = x + logoSize + 8
+		} else {
+			log.Println("Failed to place team logo", logoPath, "error:", pdf.Error())
+			pdf.ClearError()
+		}
+	}
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetXY(textX, y)
+	pdf.CellFormat(width-(textX-x), bannerHeight, team.Name, "", 0, "L", false, 0, "")
+}
+
+// Draws the away and home color bands across the top of a page pdf and
+// leaves the cursor just underneath them.
+func DrawTeamBanner(pdf *fpdf.Fpdf, teams LiveGameTeams, config ConfigData) {
+	pageWidth, _ := pdf.GetPageSize()
+	left, top, right, _ := pdf.GetMargins()
+	halfWidth := (pageWidth - left - right) / 2
+	pdf.SetFontSize(12)
+	drawTeamBand(pdf, teams.Away, left, top, halfWidth, config)
+	drawTeamBand(pdf, teams.Home, left+halfWidth, top, halfWidth, config)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetFontSize(8)
+	pdf.SetXY(left, top+bannerHeight+stripeHeight+8)
+}
@@ -0,0 +1,168 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+const (
+	ChatKindSlack   = "slack"
+	ChatKindDiscord = "discord"
+)
+
+// Reads CHAT_WEBHOOKS, a JSON list like
+// [{"Kind": "slack", "URL": "https://hooks.slack.com/...", "Teams": ["Minnesota Twins"]}]
+func ChatWebhooksFromEnv() []ChatWebhookConfig {
+	var chatWebhooks []ChatWebhookConfig
+	value := os.Getenv("CHAT_WEBHOOKS")
+	if value == "" {
+		return chatWebhooks
+	}
+	err := json.Unmarshal([]byte(value), &chatWebhooks)
+	if err != nil {
+		log.Println("Failed to parse CHAT_WEBHOOKS, error:", err)
+	}
+	return chatWebhooks
+}
+
+func chatLineup(team LineupTeam) string {
+	var returnString string
+	for _, player := range team.BattingOrder {
+		returnString += fmt.Sprintf("%d. %s #%s %s\n", player.Order, player.Position, player.Number, player.Name)
+	}
+	returnString += fmt.Sprintf("SP #%s %s (%s)", team.StartingPitcher.Number,
+		team.StartingPitcher.Name, team.StartingPitcher.Throws)
+	return returnString
+}
+
+func chatUmpires(officials LineupOfficials) string {
+	return fmt.Sprintf("HP %s · 1B %s · 2B %s · 3B %s",
+		officials.Home, officials.First, officials.Second, officials.Third)
+}
+
+func chatTitle(lineup LineupReport) string {
+	return fmt.Sprintf("%s (%d-%d) @ %s (%d-%d)",
+		lineup.Away.Name, lineup.Away.Wins, lineup.Away.Losses,
+		lineup.Home.Name, lineup.Home.Wins, lineup.Home.Losses)
+}
+
+func chatSubtitle(lineup LineupReport) string {
+	return fmt.Sprintf("%s, %s - %s %s - %sf, %s",
+		lineup.Venue.Name, lineup.Venue.City, lineup.Date, lineup.Time,
+		lineup.Weather.Temp, lineup.Weather.Condition)
+}
+
+// Points chat posts at the html card when there is one. Slack and Discord
+// only take absolute links, so nothing is linked without a site URL.
+func chatCardLink(published []string, config ConfigData) string {
+	if config.SiteURL == "" {
+		return ""
+	}
+	for _, key := range published {
+		if strings.HasSuffix(key, ".html") {
+			return feedHref(config.SiteURL, "", key)
+		}
+	}
+	if len(published) > 0 {
+		return feedHref(config.SiteURL, "", published[0])
+	}
+	return ""
+}
+
+// Incoming webhook URLs are credentials, so only the host gets logged.
+func chatLogURL(webhookURL string) string {
+	parsed, err := url.Parse(webhookURL)
+	if err != nil {
+		return "invalid url"
+	}
+	return parsed.Scheme + "://" + parsed.Host + "/..."
+}
+
+// BuildSlackMessage lays the lineups out as a Block Kit message with the
+// two teams in side by side section fields.
+func BuildSlackMessage(lineup LineupReport, cardLink string) SlackMessage {
+	title := chatTitle(lineup)
+	message := SlackMessage{
+		Text: "Lineups are out: " + title,
+		Blocks: []SlackBlock{
+			{Type: "header", Text: &SlackText{Type: "plain_text", Text: title}},
+			{Type: "context", Elements: []SlackText{{Type: "mrkdwn", Text: chatSubtitle(lineup)}}},
+			{Type: "section", Fields: []SlackText{
+				{Type: "mrkdwn", Text: fmt.Sprintf("*%s*\n```%s```", lineup.Away.Name, chatLineup(lineup.Away))},
+				{Type: "mrkdwn", Text: fmt.Sprintf("*%s*\n```%s```", lineup.Home.Name, chatLineup(lineup.Home))},
+			}},
+			{Type: "context", Elements: []SlackText{{Type: "mrkdwn", Text: "Umpires: " + chatUmpires(lineup.Officials)}}},
+		},
+	}
+	if cardLink != "" {
+		message.Blocks = append(message.Blocks, SlackBlock{
+			Type: "section",
+			Text: &SlackText{Type: "mrkdwn", Text: fmt.Sprintf("<%s|Lineup card>", cardLink)},
+		})
+	}
+	return message
+}
+
+// BuildDiscordMessage puts the lineups in one embed with inline fields so
+// Discord shows them next to each other, colored after the home team.
+func BuildDiscordMessage(lineup LineupReport, cardLink string) DiscordMessage {
+	r, g, b := parseHexColor(TeamMetadata[lineup.Home.Id].PrimaryColor)
+	embed := DiscordEmbed{
+		Title:       chatTitle(lineup),
+		URL:         cardLink,
+		Description: chatSubtitle(lineup),
+		Color:       r<<16 | g<<8 | b,
+		Fields: []DiscordField{
+			{Name: lineup.Away.Name, Value: "```" + chatLineup(lineup.Away) + "```", Inline: true},
+			{Name: lineup.Home.Name, Value: "```" + chatLineup(lineup.Home) + "```", Inline: true},
+			{Name: "Umpires", Value: chatUmpires(lineup.Officials)},
+		},
+		Footer: &DiscordFooter{Text: fmt.Sprintf("Game %d", lineup.GamePk)},
+	}
+	return DiscordMessage{
+		Content: "Lineups are out: " + chatTitle(lineup),
+		Embeds:  []DiscordEmbed{embed},
+	}
+}
+
+// PostChatMessages posts the report to every Slack and Discord webhook
+// following one of the two teams, reusing the webhook retries and log.
+func PostChatMessages(ctx context.Context, report ReportData, published []string, config ConfigData) error {
+	if len(config.ChatWebhooks) == 0 {
+		return nil
+	}
+	lineup := BuildLineupReport(report)
+	cardLink := chatCardLink(published, config)
+	var postErrors []error
+	for _, chat := range config.ChatWebhooks {
+		if len(chat.Teams) > 0 && !slices.Contains(chat.Teams, lineup.Away.Name) && !slices.Contains(chat.Teams, lineup.Home.Name) {
+			continue
+		}
+		var message any
+		switch chat.Kind {
+		case ChatKindSlack:
+			message = BuildSlackMessage(lineup, cardLink)
+		case ChatKindDiscord:
+			message = BuildDiscordMessage(lineup, cardLink)
+		default:
+			postErrors = append(postErrors, fmt.Errorf("unknown chat webhook kind %q", chat.Kind))
+			continue
+		}
+		body, err := json.Marshal(message)
+		if err == nil {
+			err = deliverBody(ctx, WebhookConfig{URL: chat.URL}, chatLogURL(chat.URL), report.GamePk, body, config)
+		}
+		if err != nil {
+			postErrors = append(postErrors, fmt.Errorf("%s webhook: %w", chat.Kind, err))
+		}
+	}
+	return errors.Join(postErrors...)
+}
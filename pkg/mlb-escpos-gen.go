@@ -0,0 +1,71 @@
+package pkg
+
+import (
+	"bytes"
+	"strings"
+)
+
+const (
+	escposInit         = "\x1b@"
+	escposCodePage1252 = "\x1bt\x10"
+	escposBoldOn       = "\x1bE\x01"
+	escposBoldOff      = "\x1bE\x00"
+	escposDoubleHeight = "\x1d!\x01"
+	escposNormalSize   = "\x1d!\x00"
+	escposFeedAndCut   = "\x1dVA\x03"
+)
+
+// escposEncode converts a line to the printer's WPC1252 code page, which
+// lines up with Latin-1 for the accented names that show up in lineups.
+func escposEncode(line string) []byte {
+	var encoded []byte
+	for _, char := range line {
+		if char < 256 {
+			encoded = append(encoded, byte(char))
+		} else {
+			encoded = append(encoded, '?')
+		}
+	}
+	return encoded
+}
+
+func isEscPosTeamLine(line string) bool {
+	return strings.HasPrefix(line, "----- ") && strings.HasSuffix(line, " -----")
+}
+
+func isEscPosHeaderLine(line string) bool {
+	return (strings.HasPrefix(line, "---") && !isEscPosTeamLine(line)) ||
+		strings.HasPrefix(line, "- AL ") ||
+		strings.HasPrefix(line, "- NL ")
+}
+
+func GenerateReceiptEscPos(datastring string, config ConfigData) bytes.Buffer {
+	var mybuffer bytes.Buffer
+	mybuffer.WriteString(escposInit)
+	mybuffer.WriteString(escposCodePage1252)
+	inMatchup := true
+	for _, line := range strings.Split(datastring, "\n") {
+		if line == "" {
+			inMatchup = false
+		}
+		switch {
+		case inMatchup:
+			mybuffer.WriteString(escposBoldOn + escposDoubleHeight)
+			mybuffer.Write(escposEncode(line))
+			mybuffer.WriteString(escposNormalSize + escposBoldOff)
+		case isEscPosTeamLine(line):
+			mybuffer.WriteString(escposBoldOn)
+			mybuffer.Write(escposEncode(line))
+			mybuffer.WriteString(escposBoldOff)
+		case isEscPosHeaderLine(line):
+			mybuffer.WriteString(escposDoubleHeight)
+			mybuffer.Write(escposEncode(line))
+			mybuffer.WriteString(escposNormalSize)
+		default:
+			mybuffer.Write(escposEncode(line))
+		}
+		mybuffer.WriteString("\n")
+	}
+	mybuffer.WriteString(escposFeedAndCut)
+	return mybuffer
+}
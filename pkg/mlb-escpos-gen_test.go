@@ -0,0 +1,91 @@
+package pkg
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testReceipt = "Mets @ Phillies\n7:05 PM\n\n----- Mets -----\n1 SS Francisco Lindor\n---Bullpen---\n\n- NL East -\nPhillies 90-72"
+
+func TestGenerateReceiptEscPos(t *testing.T) {
+	data := GenerateReceiptEscPos(testReceipt, ConfigData{})
+	output := data.String()
+
+	if !strings.HasPrefix(output, escposInit+escposCodePage1252) {
+		t.Errorf("output doesn't start with init and code page: %q", output[:8])
+	}
+	if !strings.HasSuffix(output, escposFeedAndCut) {
+		t.Errorf("output doesn't end with the cut command: %q", output[len(output)-8:])
+	}
+	checks := []struct {
+		name string
+		want string
+	}{
+		{"matchup", escposBoldOn + escposDoubleHeight + "Mets @ Phillies" + escposNormalSize + escposBoldOff + "\n"},
+		{"game time", escposBoldOn + escposDoubleHeight + "7:05 PM" + escposNormalSize + escposBoldOff + "\n"},
+		{"team name", escposBoldOn + "----- Mets -----" + escposBoldOff + "\n"},
+		{"section header", escposDoubleHeight + "---Bullpen---" + escposNormalSize + "\n"},
+		{"division header", escposDoubleHeight + "- NL East -" + escposNormalSize + "\n"},
+		{"plain line", "\n1 SS Francisco Lindor\n"},
+	}
+	for _, check := range checks {
+		if !strings.Contains(output, check.want) {
+			t.Errorf("%s: %q not found in output", check.name, check.want)
+		}
+	}
+}
+
+func TestEscPosEncode(t *testing.T) {
+	got := escposEncode("Acuña ⚾")
+	want := []byte{'A', 'c', 'u', 0xf1, 'a', ' ', '?'}
+	if !bytes.Equal(got, want) {
+		t.Errorf("escposEncode = %v, want %v", got, want)
+	}
+}
+
+func TestPrintToNetworkPrinter(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			received <- nil
+			return
+		}
+		defer conn.Close()
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		data, _ := io.ReadAll(conn)
+		received <- data
+	}()
+
+	data := GenerateReceiptEscPos(testReceipt, ConfigData{})
+	err = PrintToNetworkPrinter(listener.Addr().String(), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := <-received
+	if !bytes.Equal(got, data.Bytes()) {
+		t.Errorf("printer got %d bytes, want %d", len(got), data.Len())
+	}
+}
+
+func TestPrintToNetworkPrinterRefused(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+	err = PrintToNetworkPrinter(address, GenerateReceiptEscPos(testReceipt, ConfigData{}))
+	if err == nil {
+		t.Error("printing to a closed port succeeded")
+	}
+}
@@ -0,0 +1,93 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	FeedKey        = "feed.xml"
+	FeedMaxEntries = 50
+)
+
+func TeamFeedKey(teamName string) string {
+	return path.Join(TeamIndexDir, TeamSlug(teamName)+".xml")
+}
+
+// Links are absolute when the site URL is known, otherwise relative to
+// the feed through root.
+func feedHref(siteURL string, root string, key string) string {
+	if siteURL != "" {
+		return strings.TrimSuffix(siteURL, "/") + "/" + key
+	}
+	return root + key
+}
+
+// A game shows up in the feed once its newest format has been published.
+func gamePublished(game PublishedGame, published map[string]string) string {
+	var newest string
+	for _, link := range game.Links {
+		if published[link.Href] > newest {
+			newest = published[link.Href]
+		}
+	}
+	return newest
+}
+
+// GenerateAtomFeed builds an Atom feed with one entry per published card,
+// newest first. published maps report keys to their RFC 3339 publish time.
+func GenerateAtomFeed(id string, title string, feedKey string, games []PublishedGame, published map[string]string, siteURL string, root string) (bytes.Buffer, error) {
+	var retBuff bytes.Buffer
+	feed := AtomFeed{
+		Id:     id,
+		Title:  title,
+		Author: AtomAuthor{Name: "MLB Lineup Generator"},
+		Links: []AtomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: feedHref(siteURL, root, feedKey)},
+		},
+	}
+	for _, game := range games {
+		updated := gamePublished(game, published)
+		entry := AtomEntry{
+			Id:      fmt.Sprintf("urn:mlblg:game:%d", game.GamePk),
+			Title:   fmt.Sprintf("%s @ %s - %s", game.Away, game.Home, game.Date),
+			Updated: updated,
+			Summary: fmt.Sprintf("Lineup card for %s at %s on %s", game.Away, game.Home, game.Date),
+		}
+		for _, link := range game.Links {
+			rel := "enclosure"
+			if link.Label == "HTML" {
+				rel = "alternate"
+			}
+			entry.Links = append(entry.Links, AtomLink{
+				Rel:   rel,
+				Type:  reportContentTypes[strings.TrimPrefix(path.Ext(link.Href), ".")],
+				Title: link.Label,
+				Href:  feedHref(siteURL, root, link.Href),
+			})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	// Games are sorted by game date, the feed wants publish order
+	sort.SliceStable(feed.Entries, func(i, j int) bool {
+		return feed.Entries[i].Updated > feed.Entries[j].Updated
+	})
+	if len(feed.Entries) > FeedMaxEntries {
+		feed.Entries = feed.Entries[:FeedMaxEntries]
+	}
+	if len(feed.Entries) > 0 {
+		feed.Updated = feed.Entries[0].Updated
+	} else {
+		feed.Updated = time.Now().UTC().Format(time.RFC3339)
+	}
+	retBuff.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&retBuff)
+	encoder.Indent("", "    ")
+	err := encoder.Encode(feed)
+	return retBuff, err
+}
@@ -0,0 +1,58 @@
+package pkg
+
+import (
+	_ "embed"
+	"log"
+	"os"
+	"strings"
+
+	"codeberg.org/go-pdf/fpdf"
+)
+
+const ReportFontFamily = "FreeMono"
+
+//go:embed fonts/LiberationMono-Regular.ttf
+var embeddedRegularFont []byte
+
+//go:embed fonts/LiberationMono-Bold.ttf
+var embeddedBoldFont []byte
+
+func loadFontFile(fontFile string, embedded []byte) []byte {
+	if fontFile == "" {
+		return embedded
+	}
+	fontBytes, err := os.ReadFile(fontFile)
+	if err != nil {
+		log.Println("Failed to read font", fontFile, "falling back to the embedded font. Error:", err)
+		return embedded
+	}
+	return fontBytes
+}
+
+// Registers the regular and bold report fonts on the pdf, preferring the
+// font files from the config over the ones built into the binary.
+func AddReportFonts(pdf *fpdf.Fpdf, config ConfigData) {
+	pdf.AddUTF8FontFromBytes(ReportFontFamily, "", loadFontFile(config.RegularFontFile, embeddedRegularFont))
+	pdf.AddUTF8FontFromBytes(ReportFontFamily, "B", loadFontFile(config.BoldFontFile, embeddedBoldFont))
+}
+
+func isReportHeaderLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "---") ||
+		strings.HasPrefix(trimmed, "- AL ") ||
+		strings.HasPrefix(trimmed, "- NL ")
+}
+
+// Writes the report a line at a time so section and team headers can be
+// set in bold while keeping the same layout as a single MultiCell.
+func writeReportLines(pdf *fpdf.Fpdf, width float64, lineHeight float64, datastring string) {
+	for _, line := range strings.Split(strings.TrimSuffix(datastring, "\n"), "\n") {
+		if isReportHeaderLine(line) {
+			pdf.SetFontStyle("B")
+		} else {
+			pdf.SetFontStyle("")
+		}
+		pdf.MultiCell(width, lineHeight, line, "", "L", false)
+	}
+	pdf.SetFontStyle("")
+}
@@ -0,0 +1,49 @@
+package pkg
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"log"
+	"strings"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+var reportTemplate = template.Must(
+	template.New("report.html").Funcs(template.FuncMap{
+		"inc": func(ind int) int { return ind + 1 },
+	}).ParseFS(templateFS, "templates/report.html"))
+
+type reportHTMLData struct {
+	Game      LiveGameData
+	Away      StartingList
+	Home      StartingList
+	Teams     []StartingList
+	Officials Officials
+	Standings []NamedDivisionStandings
+}
+
+func HTMLFilename(filename string) string {
+	return strings.TrimSuffix(filename, ".pdf") + ".html"
+}
+
+func GeneratePageHTML(report ReportData, config ConfigData) bytes.Buffer {
+	var mybuffer bytes.Buffer
+	data := reportHTMLData{
+		Game:      report.GameData,
+		Away:      report.AwayTeam,
+		Home:      report.HomeTeam,
+		Teams:     []StartingList{report.AwayTeam, report.HomeTeam},
+		Officials: report.Officials,
+	}
+	if report.Standings.OK {
+		data.Standings = ListDivisionStandings(report.Standings)
+	}
+	err := reportTemplate.Execute(&mybuffer, data)
+	if err != nil {
+		log.Println("Failed to render html report", report.Filename, "error:", err)
+	}
+	return mybuffer
+}
@@ -0,0 +1,209 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+const (
+	CalendarKey  = "calendar.ics"
+	CalendarDays = 14
+	// Games don't have a scheduled end, three hours covers most of them
+	calendarGameLength = 3 * time.Hour
+)
+
+func TeamCalendarKey(teamName string) string {
+	return strings.TrimSuffix(TeamIndexKey(teamName), ".html") + ".ics"
+}
+
+// FindUpcomingGames returns the watched teams' games from today through the
+// next CalendarDays days, none when the schedule can't be loaded.
+func FindUpcomingGames(ctx context.Context, config ConfigData, baseURL string) []Game {
+	start := TodayDate()
+	startDate, _ := time.Parse("2006-01-02", start)
+	end := startDate.AddDate(0, 0, CalendarDays).Format("2006-01-02")
+	games, err := findScheduledGames(ctx, config, baseURL, start, end)
+	if err != nil {
+		log.Println(err)
+	}
+	return games
+}
+
+func FindTodayGames(ctx context.Context, config ConfigData, baseURL string) []Game {
+	games, err := findScheduledGames(ctx, config, baseURL, TodayDate(), TodayDate())
+	if err != nil {
+		log.Println(err)
+	}
+	return games
+}
+
+// Returns the watched teams' games between start and end, or an error when
+// the schedule couldn't be fetched or read, which is different from a day
+// without games.
+func findScheduledGames(ctx context.Context, config ConfigData, baseURL string, start string, end string) ([]Game, error) {
+	var returnGames []Game
+	url := fmt.Sprintf("%s/api/v1/schedule?sportId=1&startDate=%s&endDate=%s", baseURL, start, end)
+	body, ok := GetURLBody(ctx, url)
+	if !ok {
+		return returnGames, fmt.Errorf("failed to fetch the schedule for %s to %s", start, end)
+	}
+	var ScheduleResponse Schedule
+	err := json.Unmarshal(body, &ScheduleResponse)
+	if err != nil {
+		return returnGames, fmt.Errorf("failed to unmarshal schedule information: %w", err)
+	}
+	for _, date := range ScheduleResponse.Dates {
+		for _, game := range date.Games {
+			away := game.Teams.Away.Team.Name
+			home := game.Teams.Home.Team.Name
+			if slices.Contains(config.WatchTeams, away) || slices.Contains(config.WatchTeams, home) {
+				returnGames = append(returnGames, game)
+			}
+		}
+	}
+	return returnGames, nil
+}
+
+// Escapes TEXT values per RFC 5545.
+func icsEscape(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+	return replacer.Replace(value)
+}
+
+// Folds content lines longer than 75 octets without splitting a rune.
+func icsLine(buffer *bytes.Buffer, line string) {
+	for len(line) > 75 {
+		cut := 75
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		buffer.WriteString(line[:cut] + "\r\n")
+		line = " " + line[cut:]
+	}
+	buffer.WriteString(line + "\r\n")
+}
+
+// Picks the link an event should point at, the html card when there is one.
+func cardLink(game PublishedGame) string {
+	for _, label := range []string{"HTML", "PAGE", "RECEIPT"} {
+		for _, link := range game.Links {
+			if link.Label == label {
+				return link.Href
+			}
+		}
+	}
+	return ""
+}
+
+// GenerateCalendar builds an iCalendar file of games. cards maps a gamePk
+// to its published lineup card's absolute link, or to "" when the card is
+// out but there's no site URL to link it from. DTSTAMP only moves once a day so the
+// output stays the same between runs until something actually changes.
+func GenerateCalendar(name string, games []Game, cards map[int]string) bytes.Buffer {
+	var mybuffer bytes.Buffer
+	stamp := time.Now().UTC().Truncate(24 * time.Hour).Format("20060102T150405Z")
+	icsLine(&mybuffer, "BEGIN:VCALENDAR")
+	icsLine(&mybuffer, "VERSION:2.0")
+	icsLine(&mybuffer, "PRODID:-//hasjo//MLB Lineup Generator//EN")
+	icsLine(&mybuffer, "CALSCALE:GREGORIAN")
+	icsLine(&mybuffer, "METHOD:PUBLISH")
+	icsLine(&mybuffer, "X-WR-CALNAME:"+icsEscape(name))
+	for _, game := range games {
+		start, err := time.Parse(time.RFC3339, game.GameDate)
+		if err != nil {
+			log.Println("Skipping game", game.GamePk, "with bad start time", game.GameDate)
+			continue
+		}
+		description := "Lineup card not published yet."
+		card, published := cards[game.GamePk]
+		if published && card != "" {
+			description = "Lineup card: " + card
+		} else if published {
+			description = "Lineup card published."
+		}
+		icsLine(&mybuffer, "BEGIN:VEVENT")
+		icsLine(&mybuffer, fmt.Sprintf("UID:%d@mlblg", game.GamePk))
+		icsLine(&mybuffer, "DTSTAMP:"+stamp)
+		icsLine(&mybuffer, "DTSTART:"+start.UTC().Format("20060102T150405Z"))
+		icsLine(&mybuffer, "DTEND:"+start.Add(calendarGameLength).UTC().Format("20060102T150405Z"))
+		icsLine(&mybuffer, "SUMMARY:"+icsEscape(fmt.Sprintf("%s @ %s",
+			game.Teams.Away.Team.Name, game.Teams.Home.Team.Name)))
+		if game.Venue.Name != "" {
+			icsLine(&mybuffer, "LOCATION:"+icsEscape(game.Venue.Name))
+		}
+		icsLine(&mybuffer, "DESCRIPTION:"+icsEscape(description))
+		if card != "" {
+			icsLine(&mybuffer, "URL:"+card)
+		}
+		if game.Status.DetailedState == "Postponed" || game.Status.DetailedState == "Cancelled" {
+			icsLine(&mybuffer, "STATUS:CANCELLED")
+		} else {
+			icsLine(&mybuffer, "STATUS:CONFIRMED")
+		}
+		icsLine(&mybuffer, "END:VEVENT")
+	}
+	icsLine(&mybuffer, "END:VCALENDAR")
+	return mybuffer
+}
+
+// Skips the write when the stored object already has the same content.
+func putIfChanged(ctx context.Context, storage Storage, key string, data []byte, contentType string) error {
+	existing, err := storage.Get(ctx, key)
+	if err == nil && bytes.Equal(existing, data) {
+		return nil
+	}
+	return storage.Put(ctx, key, data, contentType)
+}
+
+// PublishCalendars writes calendar.ics for the whole watchlist and
+// team/<team>.ics for every watched team, linking each game to its lineup
+// card once it shows up in the manifest.
+func PublishCalendars(ctx context.Context, storage Storage, games []Game, config ConfigData) error {
+	// An empty schedule is far more likely a failed lookup than a real one,
+	// so the calendars from the last run are kept
+	if len(games) == 0 {
+		return nil
+	}
+	manifest, err := LoadManifest(ctx, storage)
+	if err != nil {
+		return err
+	}
+	cards := make(map[int]string)
+	for _, published := range CollectPublishedGames(ManifestKeys(manifest)) {
+		// Calendar clients can't resolve a relative link, so without a
+		// site URL the card is only mentioned
+		link := cardLink(published)
+		if link != "" && config.SiteURL != "" {
+			link = feedHref(config.SiteURL, "", link)
+		} else {
+			link = ""
+		}
+		cards[published.GamePk] = link
+	}
+	calendar := GenerateCalendar("MLB Watchlist", games, cards)
+	err = putIfChanged(ctx, storage, CalendarKey, calendar.Bytes(), "text/calendar")
+	if err != nil {
+		return err
+	}
+	for _, team := range config.WatchTeams {
+		var teamGames []Game
+		for _, game := range games {
+			if game.Teams.Away.Team.Name == team || game.Teams.Home.Team.Name == team {
+				teamGames = append(teamGames, game)
+			}
+		}
+		teamCalendar := GenerateCalendar(team, teamGames, cards)
+		err = putIfChanged(ctx, storage, TeamCalendarKey(team), teamCalendar.Bytes(), "text/calendar")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func testCalendarGames() []Game {
+	var games []Game
+	for ind, matchup := range [][2]string{{"Minnesota Twins", "Detroit Tigers"}, {"New York Mets", "Philadelphia Phillies"}} {
+		game := Game{GamePk: 776543 + ind, GameDate: "2025-08-11T23:10:00Z", OfficialDate: "2025-08-11"}
+		game.Teams.Away.Team.Name = matchup[0]
+		game.Teams.Home.Team.Name = matchup[1]
+		games = append(games, game)
+	}
+	return games
+}
+
+func TestPublishCalendars(t *testing.T) {
+	for _, siteURL := range []string{"", "https://cards.example.com"} {
+		ctx := context.Background()
+		storage := NewMemoryStorage()
+		config := ConfigData{WatchTeams: []string{"Minnesota Twins", "New York Mets"}, SiteURL: siteURL}
+		report := testReport(776543, "Minnesota Twins", "Detroit Tigers")
+		_, err := PublishReport(ctx, storage, report, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = PublishIndexPages(ctx, storage, ReportKeys(report, config), config)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = PublishCalendars(ctx, storage, testCalendarGames(), config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, contentType, ok := storage.Object(CalendarKey)
+		if !ok || contentType != "text/calendar" {
+			t.Fatalf("no calendar.ics, %q", contentType)
+		}
+		// Unfold the content lines before looking at them
+		calendar := strings.ReplaceAll(string(data), "\r\n ", "")
+		if strings.Count(calendar, "BEGIN:VEVENT") != 2 {
+			t.Errorf("calendar has %d events, want 2", strings.Count(calendar, "BEGIN:VEVENT"))
+		}
+		cardURL := siteURL + "/page/" + HTMLFilename(report.Filename)
+		if siteURL == "" {
+			if strings.Contains(calendar, "URL:") || strings.Contains(calendar, "page/") {
+				t.Errorf("calendar links a card without a site URL:\n%s", calendar)
+			}
+			if !strings.Contains(calendar, "DESCRIPTION:Lineup card published.") {
+				t.Errorf("calendar doesn't mention the published card:\n%s", calendar)
+			}
+		} else if !strings.Contains(calendar, "URL:"+cardURL) || !strings.Contains(calendar, "Lineup card: "+cardURL) {
+			t.Errorf("calendar doesn't link %s:\n%s", cardURL, calendar)
+		}
+		if !strings.Contains(calendar, "DESCRIPTION:Lineup card not published yet.") {
+			t.Error("the Mets game isn't waiting on its card")
+		}
+		teamData, _, ok := storage.Object(TeamCalendarKey("New York Mets"))
+		if !ok || strings.Count(string(teamData), "BEGIN:VEVENT") != 1 {
+			t.Errorf("Mets calendar:\n%s", teamData)
+		}
+	}
+}
+
+func TestICSLineFolding(t *testing.T) {
+	var buffer bytes.Buffer
+	line := "DESCRIPTION:" + strings.Repeat("é", 60)
+	icsLine(&buffer, line)
+	output := buffer.String()
+	for _, folded := range strings.Split(strings.TrimSuffix(output, "\r\n"), "\r\n") {
+		if len(folded) > 75 || !utf8.ValidString(folded) {
+			t.Errorf("folded line of %d octets: %q", len(folded), folded)
+		}
+	}
+	if unfolded := strings.ReplaceAll(output, "\r\n ", ""); unfolded != line+"\r\n" {
+		t.Errorf("unfolds to %q", unfolded)
+	}
+}
@@ -0,0 +1,263 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata"
+)
+
+const (
+	GamesIndexKey = "games.html"
+	TeamIndexDir  = "team"
+)
+
+// Report filenames look like 2025-08-11-Minnesota-Twins-at-Detroit-Tigers-776543.pdf
+var reportKeyPattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})-(.+)-at-(.+)-(\d+)\.([a-z]+)$`)
+
+var indexTemplate = template.Must(template.ParseFS(templateFS, "templates/index.html"))
+
+var redirectTemplate = template.Must(template.ParseFS(templateFS, "templates/redirect.html"))
+
+// The order links show up in next to each game.
+var linkLabels = []struct {
+	Prefix    string
+	Extension string
+	Label     string
+}{
+	{PagePrefix, "pdf", "PAGE"},
+	{ReceiptPrefix, "pdf", "RECEIPT"},
+	{PagePrefix, "html", "HTML"},
+	{PagePrefix, "json", "JSON"},
+	{PagePrefix, "md", "MARKDOWN"},
+	{PagePrefix, "txt", "TEXT"},
+}
+
+type indexDate struct {
+	Date  string
+	Games []PublishedGame
+}
+
+type indexPageData struct {
+	Title     string
+	Root      string
+	Feed      string
+	TodayDate string
+	Today     []PublishedGame
+	Dates     []indexDate
+	Teams     []PublishedLink
+}
+
+func TeamSlug(teamName string) string {
+	slug := strings.ToLower(teamName)
+	slug = strings.ReplaceAll(slug, ".", "")
+	return strings.ReplaceAll(slug, " ", "-")
+}
+
+func TeamIndexKey(teamName string) string {
+	return path.Join(TeamIndexDir, TeamSlug(teamName)+".html")
+}
+
+// Turns the dashed team name from a filename back into the display name.
+func teamFromFilePart(part string) string {
+	for _, team := range AllTeams {
+		if strings.ReplaceAll(team, " ", "-") == part {
+			return team
+		}
+	}
+	return strings.ReplaceAll(part, "-", " ")
+}
+
+// Returns the MLB schedule date, which follows US eastern time.
+func TodayDate() string {
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		location = time.UTC
+	}
+	return time.Now().In(location).Format("2006-01-02")
+}
+
+// CollectPublishedGames groups published report keys by game so every
+// format of a card can be linked together, newest games first.
+func CollectPublishedGames(keys []string) []PublishedGame {
+	gamesByName := make(map[string]*PublishedGame)
+	foundLinks := make(map[string]map[string]string)
+	for _, key := range keys {
+		prefix, filename := path.Split(key)
+		prefix = strings.TrimSuffix(prefix, "/")
+		match := reportKeyPattern.FindStringSubmatch(filename)
+		if match == nil {
+			continue
+		}
+		name := strings.TrimSuffix(filename, "."+match[5])
+		game, ok := gamesByName[name]
+		if !ok {
+			gamePk, _ := strconv.Atoi(match[4])
+			game = &PublishedGame{
+				Name:   name,
+				Date:   match[1],
+				GamePk: gamePk,
+				Away:   teamFromFilePart(match[2]),
+				Home:   teamFromFilePart(match[3]),
+			}
+			gamesByName[name] = game
+			foundLinks[name] = make(map[string]string)
+		}
+		foundLinks[name][prefix+"."+match[5]] = key
+	}
+	var games []PublishedGame
+	for name, game := range gamesByName {
+		for _, link := range linkLabels {
+			if key, ok := foundLinks[name][link.Prefix+"."+link.Extension]; ok {
+				game.Links = append(game.Links, PublishedLink{Label: link.Label, Href: key})
+			}
+		}
+		games = append(games, *game)
+	}
+	sort.Slice(games, func(i, j int) bool {
+		if games[i].Date != games[j].Date {
+			return games[i].Date > games[j].Date
+		}
+		if games[i].Away != games[j].Away {
+			return games[i].Away < games[j].Away
+		}
+		return games[i].GamePk < games[j].GamePk
+	})
+	return games
+}
+
+func withRoot(games []PublishedGame, root string) []PublishedGame {
+	var rooted []PublishedGame
+	for _, game := range games {
+		rootedGame := game
+		rootedGame.Links = nil
+		for _, link := range game.Links {
+			rootedGame.Links = append(rootedGame.Links, PublishedLink{Label: link.Label, Href: root + link.Href})
+		}
+		rooted = append(rooted, rootedGame)
+	}
+	return rooted
+}
+
+func GenerateIndexPage(title string, games []PublishedGame, teams []string, root string, feed string) (bytes.Buffer, error) {
+	var retBuff bytes.Buffer
+	data := indexPageData{
+		Title:     title,
+		Root:      root,
+		Feed:      feed,
+		TodayDate: TodayDate(),
+	}
+	for _, game := range withRoot(games, root) {
+		if game.Date == data.TodayDate {
+			data.Today = append(data.Today, game)
+			continue
+		}
+		if len(data.Dates) == 0 || data.Dates[len(data.Dates)-1].Date != game.Date {
+			data.Dates = append(data.Dates, indexDate{Date: game.Date})
+		}
+		last := &data.Dates[len(data.Dates)-1]
+		last.Games = append(last.Games, game)
+	}
+	for _, team := range teams {
+		data.Teams = append(data.Teams, PublishedLink{Label: team, Href: root + TeamIndexKey(team)})
+	}
+	err := indexTemplate.Execute(&retBuff, data)
+	return retBuff, err
+}
+
+func publishedTeams(games []PublishedGame) []string {
+	var teams []string
+	seen := make(map[string]bool)
+	for _, game := range games {
+		for _, team := range []string{game.Away, game.Home} {
+			if !seen[team] {
+				seen[team] = true
+				teams = append(teams, team)
+			}
+		}
+	}
+	sort.Strings(teams)
+	return teams
+}
+
+// PublishIndexPages makes sure every given report key is in the manifest
+// and rebuilds games.html, feed.xml and the per team pages and feeds from
+// it when any were missing. Callers pass every key of the reports they
+// published, not only the ones written this run, so a card whose run died
+// before it reached the manifest is picked up by the next one. Nothing is
+// written when the manifest already lists them all.
+// page.html and receipt.html are kept as redirects so old links still land
+// somewhere useful.
+func PublishIndexPages(ctx context.Context, storage Storage, keys []string, config ConfigData) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	manifest, changed, err := RecordPublished(ctx, storage, keys)
+	if err != nil || !changed {
+		return err
+	}
+	games := CollectPublishedGames(ManifestKeys(manifest))
+	return publishGameIndexes(ctx, storage, games, ManifestPublished(manifest), config.SiteURL)
+}
+
+func publishGameIndexes(ctx context.Context, storage Storage, games []PublishedGame, published map[string]string, siteURL string) error {
+	teams := publishedTeams(games)
+	indexPage, err := GenerateIndexPage("LINEUP CARDS", games, teams, "", FeedKey)
+	if err != nil {
+		return err
+	}
+	err = storage.Put(ctx, GamesIndexKey, indexPage.Bytes(), "text/html")
+	if err != nil {
+		return err
+	}
+	feed, err := GenerateAtomFeed("urn:mlblg:feed", "Lineup Cards", FeedKey, games, published, siteURL, "")
+	if err != nil {
+		return err
+	}
+	err = storage.Put(ctx, FeedKey, feed.Bytes(), "application/atom+xml")
+	if err != nil {
+		return err
+	}
+	for _, team := range teams {
+		var teamGames []PublishedGame
+		for _, game := range games {
+			if game.Away == team || game.Home == team {
+				teamGames = append(teamGames, game)
+			}
+		}
+		teamPage, err := GenerateIndexPage(strings.ToUpper(team), teamGames, nil, "../", "../"+TeamFeedKey(team))
+		if err != nil {
+			return err
+		}
+		err = storage.Put(ctx, TeamIndexKey(team), teamPage.Bytes(), "text/html")
+		if err != nil {
+			return err
+		}
+		teamFeed, err := GenerateAtomFeed("urn:mlblg:feed:"+TeamSlug(team), team+" Lineup Cards", TeamFeedKey(team), teamGames, published, siteURL, "../")
+		if err != nil {
+			return err
+		}
+		err = storage.Put(ctx, TeamFeedKey(team), teamFeed.Bytes(), "application/atom+xml")
+		if err != nil {
+			return err
+		}
+	}
+	var redirect bytes.Buffer
+	err = redirectTemplate.Execute(&redirect, GamesIndexKey)
+	if err != nil {
+		return err
+	}
+	for _, key := range []string{"page.html", "receipt.html"} {
+		err = storage.Put(ctx, key, redirect.Bytes(), "text/html")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+)
+
+// Bump this whenever a field in LineupReport changes meaning or goes away,
+// and add a matching schema/lineup-report.v<N>.schema.json.
+const LineupReportVersion = 1
+
+func JSONFilename(filename string) string {
+	return strings.TrimSuffix(filename, ".pdf") + ".json"
+}
+
+func buildLineupTeam(team StartingList, info Team) LineupTeam {
+	returnTeam := LineupTeam{
+		Id:     info.Id,
+		Name:   team.TeamName,
+		Wins:   info.Record.Wins,
+		Losses: info.Record.Losses,
+		StartingPitcher: LineupPitcher{
+			Id:     team.Pitcher.Id,
+			Name:   team.Pitcher.Name,
+			Number: team.Pitcher.Number,
+			Throws: team.Pitcher.Handed,
+		},
+		BattingOrder: []LineupBatter{},
+		Bullpen:      []LineupPitcher{},
+		Bench:        []LineupPlayer{},
+	}
+	for ind, player := range team.BattingOrder {
+		returnTeam.BattingOrder = append(returnTeam.BattingOrder, LineupBatter{
+			Order:    ind + 1,
+			Id:       player.Id,
+			Name:     player.Name,
+			Number:   player.JerseyNumber,
+			Position: player.Position,
+		})
+	}
+	for _, pitcher := range team.Bullpen.Bullpen {
+		returnTeam.Bullpen = append(returnTeam.Bullpen, LineupPitcher{
+			Id:     pitcher.Id,
+			Name:   pitcher.Name,
+			Number: pitcher.Number,
+			Throws: pitcher.Handed,
+		})
+	}
+	for _, player := range team.Bench.Bench {
+		returnTeam.Bench = append(returnTeam.Bench, LineupPlayer{
+			Id:     player.Id,
+			Name:   player.Name,
+			Number: player.Number,
+		})
+	}
+	return returnTeam
+}
+
+func BuildLineupReport(report ReportData) LineupReport {
+	game := report.GameData
+	returnReport := LineupReport{
+		Version:     LineupReportVersion,
+		GamePk:      report.GamePk,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Date:        game.Datetime.OfficialDate,
+		Time:        game.Datetime.Time + game.Datetime.Ampm,
+		Venue: LineupVenue{
+			Name:  game.Venue.Name,
+			City:  game.Venue.Location.City,
+			State: game.Venue.Location.StateAbbrev,
+		},
+		Weather: LineupWeather{
+			Temp:      game.Weather.Temp,
+			Condition: game.Weather.Condition,
+		},
+		Away: buildLineupTeam(report.AwayTeam, game.Teams.Away),
+		Home: buildLineupTeam(report.HomeTeam, game.Teams.Home),
+		Officials: LineupOfficials{
+			Home:   report.Officials.Home,
+			First:  report.Officials.First,
+			Second: report.Officials.Second,
+			Third:  report.Officials.Third,
+		},
+		Standings: []LineupDivision{},
+	}
+	if report.Standings.OK {
+		returnReport.Standings = BuildLineupStandings(report.Standings)
+	}
+	return returnReport
+}
+
+func BuildLineupStandings(standings StandingsData) []LineupDivision {
+	returnStandings := []LineupDivision{}
+	for _, division := range ListDivisionStandings(standings) {
+		addDivision := LineupDivision{Name: division.Name}
+		for _, team := range division.Teams {
+			addDivision.Teams = append(addDivision.Teams, LineupStandingsTeam{
+				Abbreviation: team.Abbreviation,
+				GamesBack:    team.DivisionGamesBack,
+			})
+		}
+		returnStandings = append(returnStandings, addDivision)
+	}
+	return returnStandings
+}
+
+func GenerateLineupJSON(report ReportData, config ConfigData) bytes.Buffer {
+	var mybuffer bytes.Buffer
+	encoder := json.NewEncoder(&mybuffer)
+	encoder.SetIndent("", "    ")
+	err := encoder.Encode(BuildLineupReport(report))
+	if err != nil {
+		log.Println("Failed to encode json report", report.Filename, "error:", err)
+	}
+	return mybuffer
+}
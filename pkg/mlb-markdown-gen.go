@@ -0,0 +1,76 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+func MarkdownFilename(filename string) string {
+	return strings.TrimSuffix(filename, ".pdf") + ".md"
+}
+
+func markdownEscape(cell string) string {
+	return strings.ReplaceAll(cell, "|", "\\|")
+}
+
+func markdownTeam(team LineupTeam) string {
+	var returnString string
+	returnString += fmt.Sprintf("### %s\n\n", markdownEscape(team.Name))
+	returnString += "| # | Pos | No. | Name |\n|--:|:--|--:|:--|\n"
+	for _, player := range team.BattingOrder {
+		returnString += fmt.Sprintf("| %d | %s | %s | %s |\n",
+			player.Order,
+			markdownEscape(player.Position),
+			markdownEscape(player.Number),
+			markdownEscape(player.Name))
+	}
+	returnString += fmt.Sprintf("| | P | %s | %s (%s) |\n\n",
+		markdownEscape(team.StartingPitcher.Number),
+		markdownEscape(team.StartingPitcher.Name),
+		markdownEscape(team.StartingPitcher.Throws))
+	returnString += "**Bullpen**\n\n| Throws | No. | Name |\n|:--|--:|:--|\n"
+	for _, pitcher := range team.Bullpen {
+		returnString += fmt.Sprintf("| %s | %s | %s |\n",
+			markdownEscape(pitcher.Throws),
+			markdownEscape(pitcher.Number),
+			markdownEscape(pitcher.Name))
+	}
+	returnString += "\n**Bench**\n\n| No. | Name |\n|--:|:--|\n"
+	for _, player := range team.Bench {
+		returnString += fmt.Sprintf("| %s | %s |\n",
+			markdownEscape(player.Number),
+			markdownEscape(player.Name))
+	}
+	returnString += "\n"
+	return returnString
+}
+
+func GenerateLineupMarkdown(report ReportData, config ConfigData) bytes.Buffer {
+	var mybuffer bytes.Buffer
+	lineup := BuildLineupReport(report)
+	fmt.Fprintf(&mybuffer, "## %s (%d-%d) @ %s (%d-%d)\n\n",
+		markdownEscape(lineup.Away.Name), lineup.Away.Wins, lineup.Away.Losses,
+		markdownEscape(lineup.Home.Name), lineup.Home.Wins, lineup.Home.Losses)
+	fmt.Fprintf(&mybuffer, "%s - %s, %s  \n%s - %s - %sf, %s\n\n",
+		lineup.Venue.Name, lineup.Venue.City, lineup.Venue.State,
+		lineup.Date, lineup.Time, lineup.Weather.Temp, lineup.Weather.Condition)
+	mybuffer.WriteString(markdownTeam(lineup.Away))
+	mybuffer.WriteString(markdownTeam(lineup.Home))
+	mybuffer.WriteString("### Officials\n\n| Base | Umpire |\n|:--|:--|\n")
+	fmt.Fprintf(&mybuffer, "| Home | %s |\n| First | %s |\n| Second | %s |\n| Third | %s |\n",
+		markdownEscape(lineup.Officials.Home),
+		markdownEscape(lineup.Officials.First),
+		markdownEscape(lineup.Officials.Second),
+		markdownEscape(lineup.Officials.Third))
+	if len(lineup.Standings) > 0 {
+		mybuffer.WriteString("\n### Standings\n\n| Division | Team | GB |\n|:--|:--|--:|\n")
+		for _, division := range lineup.Standings {
+			for _, team := range division.Teams {
+				fmt.Fprintf(&mybuffer, "| %s | %s | %s |\n",
+					division.Name, team.Abbreviation, team.GamesBack)
+			}
+		}
+	}
+	return mybuffer
+}
@@ -9,18 +9,23 @@ import (
 	"codeberg.org/go-pdf/fpdf"
 )
 
-func FindPageLength(datastring string) int {
+const (
+	pageQRSize    = 72.0
+	receiptQRSize = 30.0
+)
+
+func FindPageLength(datastring string, config ConfigData) int {
 	newInit := fpdf.InitType{
 		OrientationStr: "P",
 		UnitStr:        "mm",
 		Size:           fpdf.SizeType{Wd: 80, Ht: 10000},
-		FontDirStr:     "./",
+		FontDirStr:     "",
 	}
 	pdf := fpdf.NewCustom(&newInit)
 	pdf.SetMargins(0, 0, 0)
 	pdf.AddPage()
-	pdf.AddUTF8Font("FreeMono", "", "LiberationMono-Regular.ttf")
-	pdf.SetFont("FreeMono", "", 8)
+	AddReportFonts(pdf, config)
+	pdf.SetFont(ReportFontFamily, "", 8)
 	databytes := []byte(datastring)
 	lines := pdf.SplitLines(databytes, 74)
 	return len(lines)
@@ -41,64 +46,63 @@ func CreateReportDirs(config ConfigData) {
 	}
 }
 
-func GenerateReportPDF(datastring string, filename string, config ConfigData) {
+func buildPagePDF(report ReportData, config ConfigData) *fpdf.Fpdf {
 	pdf := fpdf.New("P", "pt", "Letter", "")
 	pdf.AddPage()
-	pdf.AddUTF8Font("FreeMono", "", "LiberationMono-Regular.ttf")
-	pdf.SetFont("FreeMono", "", 8)
-	pdf.MultiCell(0, 8, datastring, "", "L", false)
-	err := pdf.OutputFileAndClose(filename)
-	if err != nil {
-		log.Fatal("FAILURE TO WRITE PDF OUTPUT", err)
+	AddReportFonts(pdf, config)
+	pdf.SetFont(ReportFontFamily, "", 8)
+	if config.PlainPages == false {
+		DrawTeamBanner(pdf, report.GameData.Teams, config)
+	}
+	writeReportLines(pdf, 0, 8, report.PageData)
+	if config.GamedayQR {
+		_, pageHeight := pdf.GetPageSize()
+		left, _, _, bottom := pdf.GetMargins()
+		qrY := pdf.GetY() + 8
+		if qrY+pageQRSize > pageHeight-bottom {
+			pdf.AddPage()
+			qrY = pdf.GetY()
+		}
+		DrawGamedayQR(pdf, report.GamePk, left, qrY, pageQRSize, config)
 	}
+	return pdf
 }
 
-func GeneratePagePDF(datastring string, config ConfigData) bytes.Buffer {
+func GeneratePagePDF(report ReportData, config ConfigData) bytes.Buffer {
 	var mybuffer bytes.Buffer
-	pdf := fpdf.New("P", "pt", "Letter", "")
-	pdf.AddPage()
-	pdf.AddUTF8Font("FreeMono", "", "LiberationMono-Regular.ttf")
-	pdf.SetFont("FreeMono", "", 8)
-	pdf.MultiCell(0, 8, datastring, "", "L", false)
+	pdf := buildPagePDF(report, config)
 	pdf.Output(&mybuffer)
 	return mybuffer
 }
 
-func GenerateReportPDFReceipt(datastring string, filename string, config ConfigData) {
-	pageLength := FindPageLength(datastring)
+func buildReceiptPDF(report ReportData, config ConfigData) *fpdf.Fpdf {
+	pageLength := FindPageLength(report.ReceiptData, config)
+	pageHeight := float64(pageLength) * 3.25
+	if config.GamedayQR {
+		pageHeight += receiptQRSize + 4
+	}
 	newInit := fpdf.InitType{
 		OrientationStr: "P",
 		UnitStr:        "mm",
-		Size:           fpdf.SizeType{Wd: 80, Ht: float64(float64(pageLength) * 3.25)},
+		Size:           fpdf.SizeType{Wd: 80, Ht: pageHeight},
 		FontDirStr:     "",
 	}
 	pdf := fpdf.NewCustom(&newInit)
 	pdf.SetMargins(0, 0, 0)
+	pdf.SetAutoPageBreak(false, 0)
 	pdf.AddPage()
-	pdf.AddUTF8Font("FreeMono", "", "LiberationMono-Regular.ttf")
-	pdf.SetFont("FreeMono", "", 8)
-	pdf.MultiCell(80, 3, datastring, "", "L", false)
-	err := pdf.OutputFileAndClose(filename)
-	if err != nil {
-		log.Fatal("FAILURE TO WRITE PDF OUTPUT", err)
+	AddReportFonts(pdf, config)
+	pdf.SetFont(ReportFontFamily, "", 8)
+	writeReportLines(pdf, 80, 3, report.ReceiptData)
+	if config.GamedayQR {
+		DrawGamedayQR(pdf, report.GamePk, (80-receiptQRSize)/2, pageHeight-receiptQRSize-2, receiptQRSize, config)
 	}
+	return pdf
 }
 
-func GenerateReceiptPDF(datastring string, config ConfigData) bytes.Buffer {
+func GenerateReceiptPDF(report ReportData, config ConfigData) bytes.Buffer {
 	var mybuffer bytes.Buffer
-	pageLength := FindPageLength(datastring)
-	newInit := fpdf.InitType{
-		OrientationStr: "P",
-		UnitStr:        "mm",
-		Size:           fpdf.SizeType{Wd: 80, Ht: float64(float64(pageLength) * 3.25)},
-		FontDirStr:     "",
-	}
-	pdf := fpdf.NewCustom(&newInit)
-	pdf.SetMargins(0, 0, 0)
-	pdf.AddPage()
-	pdf.AddUTF8Font("FreeMono", "", "LiberationMono-Regular.ttf")
-	pdf.SetFont("FreeMono", "", 8)
-	pdf.MultiCell(80, 3, datastring, "", "L", false)
+	pdf := buildReceiptPDF(report, config)
 	pdf.Output(&mybuffer)
 	return mybuffer
 }
@@ -0,0 +1,51 @@
+package pkg
+
+import (
+	"bytes"
+	"image/png"
+	"log"
+	"strconv"
+	"strings"
+
+	"codeberg.org/go-pdf/fpdf"
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/qr"
+)
+
+const DefaultGamedayURL = "https://www.mlb.com/gameday/{pk}"
+
+func GamedayURL(gamePk int, config ConfigData) string {
+	urlTemplate := config.GamedayURL
+	if urlTemplate == "" {
+		urlTemplate = DefaultGamedayURL
+	}
+	return strings.ReplaceAll(urlTemplate, "{pk}", strconv.Itoa(gamePk))
+}
+
+// Puts a square QR code pointing at the game's Gameday page with its top
+// left corner at x, y. The code is rasterized at several pixels per module
+// so it stays sharp when fpdf scales it to size.
+func DrawGamedayQR(pdf *fpdf.Fpdf, gamePk int, x float64, y float64, size float64, config ConfigData) {
+	gamedayURL := GamedayURL(gamePk, config)
+	code, err := qr.Encode(gamedayURL, qr.M, qr.Auto)
+	if err != nil {
+		log.Println("Failed to encode gameday qr code", gamedayURL, "error:", err)
+		return
+	}
+	modules := code.Bounds().Dx()
+	code, err = barcode.Scale(code, modules*8, modules*8)
+	if err != nil {
+		log.Println("Failed to scale gameday qr code", gamedayURL, "error:", err)
+		return
+	}
+	var pngBuffer bytes.Buffer
+	err = png.Encode(&pngBuffer, code)
+	if err != nil {
+		log.Println("Failed to render gameday qr code", gamedayURL, "error:", err)
+		return
+	}
+	imageName := "gameday-qr-" + strconv.Itoa(gamePk)
+	imageOptions := fpdf.ImageOptions{ImageType: "PNG"}
+	pdf.RegisterImageOptionsReader(imageName, imageOptions, &pngBuffer)
+	pdf.ImageOptions(imageName, x, y, size, size, false, imageOptions, 0, "")
+}
@@ -1,6 +1,7 @@
 package pkg
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"flag"
@@ -9,8 +10,11 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/kirsle/configdir"
@@ -19,9 +23,20 @@ import (
 
 const BaseLinksURL = "https://statsapi.mlb.com"
 
-// This is a var so it can be mocked over in testing
-var GetURLBody = func(targetURL string) ([]byte, bool) {
-	resp, err := http.Get(targetURL)
+// No single fetch gets to hold up a lookup pass longer than this.
+const fetchTimeout = 30 * time.Second
+
+var fetchClient = &http.Client{Timeout: fetchTimeout}
+
+// This is a var so it can be mocked over in testing. Cancelling ctx aborts
+// the request in flight.
+var GetURLBody = func(ctx context.Context, targetURL string) ([]byte, bool) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, targetURL, nil)
+	if err != nil {
+		log.Printf("Can't retrieve %s\n", targetURL)
+		return []byte{}, false
+	}
+	resp, err := fetchClient.Do(request)
 	if err != nil {
 		log.Printf("Can't retrieve %s\n", targetURL)
 		return []byte{}, false
@@ -36,31 +51,38 @@ var GetURLBody = func(targetURL string) ([]byte, bool) {
 
 }
 
-func FindGameLinks(config ConfigData, baseURL string) []GameLink {
+func ReportFilename(date string, away string, home string, gamePk int) string {
+	awayMatchup := strings.ReplaceAll(away, " ", "-")
+	homeMatchup := strings.ReplaceAll(home, " ", "-")
+	return fmt.Sprintf(
+		"%s-%s-%d.pdf",
+		date,
+		fmt.Sprintf("%s-at-%s", awayMatchup, homeMatchup),
+		gamePk,
+	)
+}
+
+func FindGameLinks(ctx context.Context, config ConfigData, baseURL string) []GameLink {
 	var returnLinks []GameLink
 	MonitoredTeams := config.WatchTeams
 	url := fmt.Sprintf("%s/api/v1/schedule?sportId=1", baseURL)
-	resp, err := http.Get(url)
-	if err != nil {
+	body, ok := GetURLBody(ctx, url)
+	if !ok {
 		log.Println("Can't retrieve schedule endpoint, retrying in a moment...")
 		return []GameLink{}
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
 	var ScheduleResponse Schedule
-	err = json.Unmarshal(body, &ScheduleResponse)
+	err := json.Unmarshal(body, &ScheduleResponse)
 	if err != nil {
-		log.Fatal("Failed to unmarshal schedule information = ", err)
+		log.Println("Failed to unmarshal schedule information, retrying in a moment... error:", err)
+		return []GameLink{}
+	}
+	if len(ScheduleResponse.Dates) == 0 {
+		return []GameLink{}
 	}
 	for _, game := range ScheduleResponse.Dates[0].Games {
-		awayMatchup := strings.ReplaceAll(game.Teams.Away.Team.Name, " ", "-")
-		homeMatchup := strings.ReplaceAll(game.Teams.Home.Team.Name, " ", "-")
-		filename := fmt.Sprintf(
-			"%s-%s-%d.pdf",
-			ScheduleResponse.Dates[0].Date,
-			fmt.Sprintf("%s-at-%s", awayMatchup, homeMatchup),
-			game.GamePk,
-		)
+		filename := ReportFilename(ScheduleResponse.Dates[0].Date,
+			game.Teams.Away.Team.Name, game.Teams.Home.Team.Name, game.GamePk)
 		filepath := fmt.Sprintf("%s%s", config.ReportPath, filename)
 		away := game.Teams.Away.Team.Name
 		home := game.Teams.Home.Team.Name
@@ -406,29 +428,63 @@ func PrettyPrintTeamsReceipt(awayTeam StartingList, homeTeam StartingList, live
 	returnString += PrettyPrintBenchReceipt(homeTeam)
 	return returnString
 }
-func GetPitcherInformation(infoURL string) BullpenInfo {
-	baseURL := "https://statsapi.mlb.com"
-	URL := baseURL + infoURL
-	resp, err := http.Get(URL)
-	if err != nil {
-		log.Println("Unable to get Pitcher Info... Will try again later. Error:", err)
-		return BullpenInfo{OK: false}
+
+// The people endpoint takes a list of ids, so a whole game's pitchers
+// come back in one round trip instead of one per pitcher.
+const peopleBatchSize = 100
+
+// GetPitchersInformation looks up every pitcher in personIds, keyed by id.
+// ok is false when any request fails or an id doesn't come back.
+func GetPitchersInformation(ctx context.Context, personIds []int) (map[int]BullpenInfo, bool) {
+	pitchers := make(map[int]BullpenInfo)
+	for start := 0; start < len(personIds); start += peopleBatchSize {
+		batch := personIds[start:min(start+peopleBatchSize, len(personIds))]
+		var idStrings []string
+		for _, personId := range batch {
+			idStrings = append(idStrings, strconv.Itoa(personId))
+		}
+		URL := fmt.Sprintf("%s/api/v1/people?personIds=%s", BaseLinksURL, strings.Join(idStrings, ","))
+		body, ok := GetURLBody(ctx, URL)
+		if !ok {
+			log.Println("Unable to get Pitcher Info... Will try again later.")
+			return pitchers, false
+		}
+		var PitcherResponse PeopleInfo
+		err := json.Unmarshal(body, &PitcherResponse)
+		if err != nil {
+			log.Println("Unable to read Pitcher Info... Will try again later. Error:", err)
+			return pitchers, false
+		}
+		for _, PitcherData := range PitcherResponse.People {
+			pitchers[PitcherData.Id] = BullpenInfo{
+				Id:     PitcherData.Id,
+				Name:   PitcherData.FullName,
+				Number: PitcherData.PrimaryNumber,
+				Handed: PitcherData.PitchHand.Code,
+				OK:     true,
+			}
+		}
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	var PitcherResponse PeopleInfo
-	err = json.Unmarshal(body, &PitcherResponse)
-	PitcherData := PitcherResponse.People[0]
-	return BullpenInfo{
-		Name:   PitcherData.FullName,
-		Number: PitcherData.PrimaryNumber,
-		Handed: PitcherData.PitchHand.Code,
-		OK:     true,
+	for _, personId := range personIds {
+		if _, found := pitchers[personId]; !found {
+			log.Println("No Pitcher Info came back for", personId)
+			return pitchers, false
+		}
 	}
+	return pitchers, true
+}
 
+// The starter and the bullpen of a team, everyone the cards need pitcher
+// details for.
+func teamPitcherIds(inTeam LiveDataTeam) []int {
+	var personIds []int
+	if len(inTeam.Pitchers) > 0 {
+		personIds = append(personIds, inTeam.Pitchers[0])
+	}
+	return append(personIds, inTeam.Bullpen...)
 }
 
-func GenerateStartingList(inTeam LiveDataTeam) StartingList {
+func generateStartingList(inTeam LiveDataTeam, pitchers map[int]BullpenInfo) StartingList {
 	var returnList StartingList
 	returnList.TeamName = inTeam.Team.Name
 	Order := inTeam.BattingOrder
@@ -436,15 +492,17 @@ func GenerateStartingList(inTeam LiveDataTeam) StartingList {
 		IDString := fmt.Sprintf("ID%d", playerId)
 		PlayerItem := inTeam.Players[IDString]
 		returnList.BattingOrder[ind] = BatOrderInfo{
+			Id:           PlayerItem.Person.Id,
 			Position:     PlayerItem.Position.Abbreviation,
 			Name:         PlayerItem.Person.FullName,
 			JerseyNumber: PlayerItem.JerseyNumber,
 		}
 	}
-	StartingPitcher := inTeam.Pitchers[0]
-	IDString := fmt.Sprintf("ID%d", StartingPitcher)
-	PitcherItem := inTeam.Players[IDString]
-	returnList.Pitcher = GetPitcherInformation(PitcherItem.Person.Link)
+	if len(inTeam.Pitchers) == 0 {
+		returnList.OK = false
+		return returnList
+	}
+	returnList.Pitcher = pitchers[inTeam.Pitchers[0]]
 	if returnList.Pitcher.OK == false {
 		returnList.OK = false
 	} else {
@@ -453,15 +511,13 @@ func GenerateStartingList(inTeam LiveDataTeam) StartingList {
 	return returnList
 }
 
-func GenerateBullpen(inTeam LiveDataTeam) BullpenList {
+func generateBullpen(inTeam LiveDataTeam, pitchers map[int]BullpenInfo) BullpenList {
 	var returnList BullpenList
 	returnList.OK = true
 	returnList.TeamName = inTeam.Team.Name
 	Order := inTeam.Bullpen
 	for ind, playerId := range Order {
-		IDString := fmt.Sprintf("ID%d", playerId)
-		PlayerItem := inTeam.Players[IDString]
-		returnList.Bullpen = append(returnList.Bullpen, GetPitcherInformation(PlayerItem.Person.Link))
+		returnList.Bullpen = append(returnList.Bullpen, pitchers[playerId])
 		if returnList.Bullpen[ind].OK == false {
 			returnList.OK = false
 		}
@@ -477,6 +533,7 @@ func GenerateBench(inTeam LiveDataTeam) BenchList {
 		IDString := fmt.Sprintf("ID%d", playerId)
 		PlayerItem := inTeam.Players[IDString]
 		addItem := BenchInfo{
+			Id:     PlayerItem.Person.Id,
 			Name:   PlayerItem.Person.FullName,
 			Number: PlayerItem.JerseyNumber,
 		}
@@ -512,7 +569,7 @@ func PrettyPrintOfficials(officials Officials) string {
 	return returnString
 }
 
-func GenerateStandings() StandingsData {
+func GenerateStandings(ctx context.Context) StandingsData {
 	var returnData StandingsData
 	currentYear := time.Now().Year()
 	getURL := "https://bdfed.stitch.mlbinfra.com/bdfed/transform-mlb-standings?" +
@@ -521,7 +578,7 @@ func GenerateStandings() StandingsData {
 		"&standingsView=division" +
 		"&sortTemplate=3" +
 		"&season=" +
-		string(currentYear) +
+		strconv.Itoa(currentYear) +
 		"&leagueIds=103" +
 		"&leagueIds=104" +
 		"&standingsTypes=regularSeason" +
@@ -529,16 +586,19 @@ func GenerateStandings() StandingsData {
 		"&teamId=" +
 		"&hydrateAlias=noSchedule" +
 		"&favoriteTeams=sortSports=1"
-	resp, err := http.Get(getURL)
-	if err != nil {
-		log.Println("Failed to get standings... error:", err)
+	body, ok := GetURLBody(ctx, getURL)
+	if !ok {
+		log.Println("Failed to get standings...")
 		returnData.OK = false
 		return returnData
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
 	var StandingsResponse Standings
-	err = json.Unmarshal(body, &StandingsResponse)
+	err := json.Unmarshal(body, &StandingsResponse)
+	if err != nil || len(StandingsResponse.Structure.Sports) == 0 {
+		log.Println("Failed to read standings... error:", err)
+		returnData.OK = false
+		return returnData
+	}
 	var DivisionIDs = make(map[int]string)
 	for _, league := range StandingsResponse.Structure.Sports[0].Leagues {
 		for _, division := range league.Divisions {
@@ -567,6 +627,17 @@ func GenerateStandings() StandingsData {
 	return returnData
 }
 
+func ListDivisionStandings(standings StandingsData) []NamedDivisionStandings {
+	return []NamedDivisionStandings{
+		{Name: "AL West", Teams: standings.ALWest.standings[:]},
+		{Name: "AL Central", Teams: standings.ALCentral.standings[:]},
+		{Name: "AL East", Teams: standings.ALEast.standings[:]},
+		{Name: "NL West", Teams: standings.NLWest.standings[:]},
+		{Name: "NL Central", Teams: standings.NLCentral.standings[:]},
+		{Name: "NL East", Teams: standings.NLEast.standings[:]},
+	}
+}
+
 func PrettyPrintStandings(standings StandingsData) string {
 	OutLines := "- AL West - | - AL Central - | - AL East -\n" +
 		" Team | GB  |  Team  |  GB   | Team  | GB\n"
@@ -595,40 +666,46 @@ func PrettyPrintStandings(standings StandingsData) string {
 	return OutLines
 }
 
-func GeneratePreGameReport(InLink GameLink, debug bool) ReportData {
+func GeneratePreGameReport(ctx context.Context, InLink GameLink, debug bool) ReportData {
 	var ReturnReportReceipt string
 	var ReturnReportPage string
 	var Message string
+	var awayTeam, homeTeam StartingList
+	var officials Officials
 	getURL := InLink.Link
-	resp, err := http.Get(getURL)
-	if err != nil {
-		log.Println("Unable to get game info... error:", err)
+	body, ok := GetURLBody(ctx, getURL)
+	if !ok {
+		log.Println("Unable to get game info for", InLink.Matchup)
 		return ReportData{OK: false}
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
 	var LiveGameResponse LiveGame
-	err = json.Unmarshal(body, &LiveGameResponse)
+	err := json.Unmarshal(body, &LiveGameResponse)
 	if err != nil {
-		log.Fatal("CANT UNMARSHAL = ", err)
+		// Usually an error page from upstream, the next lookup tries again
+		log.Println("Unable to read game info for", InLink.Matchup, "error:", err)
+		return ReportData{OK: false}
 	}
 	var filename string
 	filename = InLink.FileMatchup
 	isLive := LiveGameResponse.GameData.Status.AbstractGameState == "Live"
 	if isLive {
-		awayTeam := GenerateStartingList(
-			LiveGameResponse.LiveData.Boxscore.Teams.Away)
-		awayTeam.Bullpen = GenerateBullpen(
-			LiveGameResponse.LiveData.Boxscore.Teams.Away)
+		// One people lookup covers the pitchers of both teams
+		pitchers, _ := GetPitchersInformation(ctx, append(
+			teamPitcherIds(LiveGameResponse.LiveData.Boxscore.Teams.Away),
+			teamPitcherIds(LiveGameResponse.LiveData.Boxscore.Teams.Home)...))
+		awayTeam = generateStartingList(
+			LiveGameResponse.LiveData.Boxscore.Teams.Away, pitchers)
+		awayTeam.Bullpen = generateBullpen(
+			LiveGameResponse.LiveData.Boxscore.Teams.Away, pitchers)
 		awayTeam.Bench = GenerateBench(
 			LiveGameResponse.LiveData.Boxscore.Teams.Away)
 		if awayTeam.OK == false || awayTeam.Bullpen.OK == false {
 			return ReportData{OK: false}
 		}
-		homeTeam := GenerateStartingList(
-			LiveGameResponse.LiveData.Boxscore.Teams.Home)
-		homeTeam.Bullpen = GenerateBullpen(
-			LiveGameResponse.LiveData.Boxscore.Teams.Home)
+		homeTeam = generateStartingList(
+			LiveGameResponse.LiveData.Boxscore.Teams.Home, pitchers)
+		homeTeam.Bullpen = generateBullpen(
+			LiveGameResponse.LiveData.Boxscore.Teams.Home, pitchers)
 		homeTeam.Bench = GenerateBench(
 			LiveGameResponse.LiveData.Boxscore.Teams.Home)
 		if homeTeam.OK == false || homeTeam.Bullpen.OK == false {
@@ -636,7 +713,7 @@ func GeneratePreGameReport(InLink GameLink, debug bool) ReportData {
 		}
 		ReturnReportReceipt += PrettyPrintTeamsReceipt(awayTeam, homeTeam, LiveGameResponse)
 		ReturnReportPage += PrettyPrintTeams(awayTeam, homeTeam, LiveGameResponse)
-		officials := GenerateUmpires(LiveGameResponse.LiveData.Boxscore.Officials)
+		officials = GenerateUmpires(LiveGameResponse.LiveData.Boxscore.Officials)
 		ReturnReportReceipt += PrettyPrintOfficials(officials)
 		ReturnReportPage += PrettyPrintOfficials(officials)
 		if debug == true {
@@ -659,45 +736,68 @@ func GeneratePreGameReport(InLink GameLink, debug bool) ReportData {
 		PageData:    ReturnReportPage,
 		Message:     Message,
 		Filename:    filename,
+		GamePk:      InLink.PK,
 		Live:        isLive,
 		OK:          true,
+		GameData:    LiveGameResponse.GameData,
+		AwayTeam:    awayTeam,
+		HomeTeam:    homeTeam,
+		Officials:   officials,
 	}
 }
 
 func GenerateFullReport(config ConfigData, debug bool) []ReportData {
+	return GenerateFullReportContext(context.Background(), config, debug)
+}
+
+// GenerateFullReportContext looks the games up on a bounded worker pool.
+// Once ctx is done no new lookups start and the fetches in flight are
+// aborted.
+func GenerateFullReportContext(ctx context.Context, config ConfigData, debug bool) []ReportData {
 	var returnData []ReportData
-	teams := config.WatchTeams
-	if len(teams) == 0 {
-		teams = []string{"Minnesota Twins"}
-	}
-	foundLinks := FindGameLinks(config, BaseLinksURL)
-	for _, link := range foundLinks {
-		newReport := GeneratePreGameReport(link, debug)
+	foundLinks := FindGameLinks(ctx, config, BaseLinksURL)
+	reports := lookupReports(ctx, len(foundLinks), func(ind int) ReportData {
+		return GeneratePreGameReport(ctx, foundLinks[ind], debug)
+	})
+	for _, newReport := range reports {
 		if newReport.OK {
 			returnData = append(returnData, newReport)
 		}
 	}
 	if len(returnData) > 0 {
-		standings := GenerateStandings()
+		standings := GenerateStandings(ctx)
 		if standings.OK == false {
 			return []ReportData{}
 		}
-		prettyStandings := PrettyPrintStandings(standings)
 		for ind := range returnData {
-			returnData[ind].ReceiptData += "\n" + prettyStandings
-			returnData[ind].PageData += "\n" + prettyStandings
+			returnData[ind] = withStandings(returnData[ind], standings)
 		}
 	}
 	return returnData
 }
 
-func GetOrHandleConfiguration() ConfigData {
-	var returnData ConfigData
+// Adds the standings to both cards of a live report.
+func withStandings(report ReportData, standings StandingsData) ReportData {
+	prettyStandings := PrettyPrintStandings(standings)
+	report.ReceiptData += "\n" + prettyStandings
+	report.PageData += "\n" + prettyStandings
+	report.Standings = standings
+	return report
+}
+
+// Returns the config directory, creating it when it doesn't exist yet.
+func ConfigDir() string {
 	configPath := configdir.LocalConfig("mlb-report-gen")
 	err := configdir.MakePath(configPath)
 	if err != nil {
 		log.Fatal("Failed to make the config directory", configPath, "exiting...")
 	}
+	return configPath
+}
+
+func GetOrHandleConfiguration() ConfigData {
+	var returnData ConfigData
+	configPath := ConfigDir()
 	configFile := filepath.Join(configPath, "config.json")
 
 	if _, err := os.Stat(configFile); os.IsNotExist(err) {
@@ -737,31 +837,45 @@ func GetOrHandleConfiguration() ConfigData {
 	return returnData
 }
 
-func RunLookup(debug bool, config ConfigData, watchlist map[string]bool) {
-	data := GenerateFullReport(config, debug)
-	for _, report := range data {
-		datapath := filepath.Join(config.ReportPath, report.Filename)
-		receiptpath := filepath.Join(config.ReceiptPath, report.Filename)
-		pagepath := filepath.Join(config.PagePath, report.Filename)
-		if report.Live == true {
-			if _, err := os.Stat(receiptpath); errors.Is(err, os.ErrNotExist) {
-				fmt.Printf("\n Writing %s\n", receiptpath)
-				GenerateReportPDFReceipt(report.ReceiptData, receiptpath, config)
-			}
-			if _, err := os.Stat(pagepath); errors.Is(err, os.ErrNotExist) {
-				fmt.Printf("\n Writing %s\n", pagepath)
-				GenerateReportPDF(report.PageData, pagepath, config)
-			}
-			delete(watchlist, datapath)
-		} else {
-			_, ok := watchlist[datapath]
-			if ok {
-				fmt.Print(".")
-			} else {
-				fmt.Printf("\n Found %s - monitoring...\n",
-					strings.SplitN(report.Message, "\n", 2)[0])
-				watchlist[datapath] = true
-			}
+// Publishes a live report locally, returning the keys that were written
+// and the publish error if any.
+func publishLocalReport(ctx context.Context, storage Storage, report ReportData, config ConfigData) ([]string, error) {
+	published, publishErr := PublishReport(ctx, storage, report, config)
+	for _, key := range published {
+		fmt.Printf("\n Wrote %s\n", filepath.Join(config.ReportPath, key))
+	}
+	if publishErr != nil {
+		log.Println("Failed to publish", report.Filename, "error:", publishErr)
+	}
+	return published, publishErr
+}
+
+// Hands the newly written keys of a report to the notifications and
+// printers. This runs after the state and indexes are saved since a slow
+// receiver or printer can take a while.
+func announceLocalReport(ctx context.Context, storage Storage, report ReportData, published []string, config ConfigData) {
+	err := NotifyPublished(ctx, report, published, config)
+	if err != nil {
+		log.Println("Failed to notify webhooks about", report.Filename, "error:", err)
+	}
+	receiptKey := ReceiptKey(report.Filename)
+	if config.ReceiptPrinter != "" && slices.Contains(published, receiptKey) {
+		fmt.Printf("\n Printing %s to %s\n", receiptKey, config.ReceiptPrinter)
+		escposData := GenerateReceiptEscPos(report.ReceiptData, config)
+		err := PrintToNetworkPrinter(config.ReceiptPrinter, escposData)
+		if err != nil {
+			log.Println("Failed to print receipt", receiptKey, "error:", err)
+		}
+	}
+	pageKey := PageKey(report.Filename)
+	if pagePrinterEnabled(config) && slices.Contains(published, pageKey) {
+		fmt.Printf("\n Printing %s\n", pageKey)
+		pageData, err := storage.Get(ctx, pageKey)
+		if err == nil {
+			err = PrintPagePDF(ctx, report.Filename, pageData, config)
+		}
+		if err != nil {
+			log.Println("Failed to print page", pageKey, "error:", err)
 		}
 	}
 }
@@ -769,13 +883,27 @@ func RunLookup(debug bool, config ConfigData, watchlist map[string]bool) {
 func RunLocal() {
 	//Setup the config dir
 	debugPtr := flag.Bool("debug", false, "Enable debug output")
+	formatPtr := flag.String("format", "", "Comma separated extra formats to write (markdown,text)")
+	pollPtr := flag.Bool("poll", false, "Look for every game once a minute instead of scheduling checks around first pitch")
 	flag.Parse()
 	debug := *debugPtr
 	config := GetOrHandleConfiguration()
-	var watchList map[string]bool
-	watchList = make(map[string]bool)
-	go RunLookup(debug, config, watchList)
-	for range time.Tick(time.Second * 60) {
-		go RunLookup(debug, config, watchList)
+	if *formatPtr != "" {
+		config.Formats = strings.Split(*formatPtr, ",")
+	}
+	// Ctrl-C or a SIGTERM aborts the current lookup and waits for it to
+	// wind down before exiting
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	state := LoadStateStore(StateFile())
+	if !*pollPtr {
+		RunScheduler(ctx, debug, config, state)
+	} else {
+		NewRunner(config, debug, state).Run(ctx, time.Second*60)
+	}
+	err := state.Save()
+	if err != nil {
+		log.Println("Failed to save the watcher state, error:", err)
 	}
+	log.Println("Shutting down")
 }
@@ -0,0 +1,33 @@
+package pkg
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestGetPitchersInformation(t *testing.T) {
+	var fetched []string
+	original := GetURLBody
+	GetURLBody = func(ctx context.Context, targetURL string) ([]byte, bool) {
+		fetched = append(fetched, targetURL)
+		return []byte(testPeople), true
+	}
+	t.Cleanup(func() { GetURLBody = original })
+
+	pitchers, ok := GetPitchersInformation(context.Background(), []int{100, 101, 200, 201})
+	if !ok {
+		t.Fatal("lookup failed")
+	}
+	if len(fetched) != 1 || !strings.HasSuffix(fetched[0], "/api/v1/people?personIds=100,101,200,201") {
+		t.Errorf("fetched %v, want one request for every id", fetched)
+	}
+	if pitcher := pitchers[200]; pitcher.Name != "Tarik Skubal" || pitcher.Number != "29" || pitcher.Handed != "L" || !pitcher.OK {
+		t.Errorf("pitcher 200 is %+v", pitcher)
+	}
+
+	_, ok = GetPitchersInformation(context.Background(), []int{100, 999})
+	if ok {
+		t.Error("an id missing from the response counted as found")
+	}
+}
@@ -0,0 +1,127 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	textPageWidth   = 80
+	textColumnWidth = (textPageWidth - 3) / 2
+)
+
+func TextFilename(filename string) string {
+	return strings.TrimSuffix(filename, ".pdf") + ".txt"
+}
+
+// textFit pads or truncates a line to exactly width runes so that the two
+// team columns stay lined up even with long names.
+func textFit(line string, width int) string {
+	runes := []rune(line)
+	if len(runes) > width {
+		return string(runes[:width])
+	}
+	return line + strings.Repeat(" ", width-len(runes))
+}
+
+func textCenter(line string) string {
+	runes := []rune(line)
+	if len(runes) >= textPageWidth {
+		return string(runes[:textPageWidth])
+	}
+	return strings.Repeat(" ", (textPageWidth-len(runes))/2) + line
+}
+
+func textColumns(left []string, right []string) string {
+	var returnString string
+	rows := len(left)
+	if len(right) > rows {
+		rows = len(right)
+	}
+	for ind := range rows {
+		var leftLine, rightLine string
+		if ind < len(left) {
+			leftLine = left[ind]
+		}
+		if ind < len(right) {
+			rightLine = right[ind]
+		}
+		returnString += strings.TrimRight(
+			textFit(leftLine, textColumnWidth)+" | "+textFit(rightLine, textColumnWidth),
+			" ") + "\n"
+	}
+	return returnString
+}
+
+func textBattingLines(team LineupTeam) []string {
+	lines := []string{
+		fmt.Sprintf("----- %s -----", team.Name),
+	}
+	for _, player := range team.BattingOrder {
+		lines = append(lines, fmt.Sprintf("%d. %2s - %2s - %s",
+			player.Order, player.Position, player.Number, player.Name))
+	}
+	lines = append(lines, fmt.Sprintf("    P - %2s - %2s - %s",
+		team.StartingPitcher.Throws, team.StartingPitcher.Number, team.StartingPitcher.Name))
+	return lines
+}
+
+func textBullpenLines(team LineupTeam) []string {
+	lines := []string{"---BULLPEN"}
+	for _, pitcher := range team.Bullpen {
+		lines = append(lines, fmt.Sprintf("%2s - %2s - %s",
+			pitcher.Throws, pitcher.Number, pitcher.Name))
+	}
+	return lines
+}
+
+func textBenchLines(team LineupTeam) []string {
+	lines := []string{"---BENCH"}
+	for _, player := range team.Bench {
+		lines = append(lines, fmt.Sprintf("%2s - %s", player.Number, player.Name))
+	}
+	return lines
+}
+
+func GenerateLineupText(report ReportData, config ConfigData) bytes.Buffer {
+	var mybuffer bytes.Buffer
+	lineup := BuildLineupReport(report)
+	mybuffer.WriteString(textCenter(fmt.Sprintf("%s (%d-%d) @ %s (%d-%d)",
+		lineup.Away.Name, lineup.Away.Wins, lineup.Away.Losses,
+		lineup.Home.Name, lineup.Home.Wins, lineup.Home.Losses)) + "\n")
+	mybuffer.WriteString(textCenter(fmt.Sprintf("%s - %s, %s",
+		lineup.Venue.Name, lineup.Venue.City, lineup.Venue.State)) + "\n")
+	mybuffer.WriteString(textCenter(fmt.Sprintf("%s - %s - %sf, %s",
+		lineup.Date, lineup.Time, lineup.Weather.Temp, lineup.Weather.Condition)) + "\n\n")
+	mybuffer.WriteString(textColumns(textBattingLines(lineup.Away), textBattingLines(lineup.Home)))
+	mybuffer.WriteString(textColumns(textBullpenLines(lineup.Away), textBullpenLines(lineup.Home)))
+	mybuffer.WriteString(textColumns(textBenchLines(lineup.Away), textBenchLines(lineup.Home)))
+	mybuffer.WriteString("\n---OFFICIALS\n")
+	fmt.Fprintf(&mybuffer, "HOME   - %s\nFIRST  - %s\nSECOND - %s\nTHIRD  - %s\n",
+		lineup.Officials.Home,
+		lineup.Officials.First,
+		lineup.Officials.Second,
+		lineup.Officials.Third)
+	for start := 0; start+3 <= len(lineup.Standings); start += 3 {
+		mybuffer.WriteString("\n")
+		divisions := lineup.Standings[start : start+3]
+		var header string
+		for _, division := range divisions {
+			header += textFit(fmt.Sprintf("- %s -", division.Name), 25) + " "
+		}
+		mybuffer.WriteString(strings.TrimRight(header, " ") + "\n")
+		for ind := range divisions[0].Teams {
+			var row string
+			for _, division := range divisions {
+				if ind < len(division.Teams) {
+					row += textFit(fmt.Sprintf("%4s %5s",
+						division.Teams[ind].Abbreviation,
+						division.Teams[ind].GamesBack), 25) + " "
+				}
+			}
+			mybuffer.WriteString(strings.TrimRight(row, " ") + "\n")
+		}
+	}
+	return mybuffer
+}
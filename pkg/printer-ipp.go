@@ -0,0 +1,176 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IPP/1.1 bits needed for a single Print-Job, RFC 8010 and 8011.
+const (
+	ippVersionMajor       = 1
+	ippVersionMinor       = 1
+	ippOpPrintJob         = 0x0002
+	ippTagOperation       = 0x01
+	ippTagJob             = 0x02
+	ippTagEnd             = 0x03
+	ippTagInteger         = 0x21
+	ippTagBegCollection   = 0x34
+	ippTagNameWithoutLang = 0x42
+	ippTagKeyword         = 0x44
+	ippTagURI             = 0x45
+	ippTagCharset         = 0x47
+	ippTagNaturalLanguage = 0x48
+	ippTagMimeMediaType   = 0x49
+	ippTagEndCollection   = 0x37
+	ippTagMemberAttrName  = 0x4A
+	ippDefaultPort        = "631"
+	ippTimeout            = 60 * time.Second
+)
+
+func ippAttribute(buffer *bytes.Buffer, tag byte, name string, value []byte) {
+	buffer.WriteByte(tag)
+	binary.Write(buffer, binary.BigEndian, uint16(len(name)))
+	buffer.WriteString(name)
+	binary.Write(buffer, binary.BigEndian, uint16(len(value)))
+	buffer.Write(value)
+}
+
+func ippInteger(value int) []byte {
+	return binary.BigEndian.AppendUint32(nil, uint32(value))
+}
+
+// Builds the Print-Job request, document data included.
+func buildIPPPrintJob(printerURI string, jobName string, copies int, tray string, pdf []byte) []byte {
+	var request bytes.Buffer
+	request.Write([]byte{ippVersionMajor, ippVersionMinor})
+	binary.Write(&request, binary.BigEndian, uint16(ippOpPrintJob))
+	binary.Write(&request, binary.BigEndian, uint32(1))
+	request.WriteByte(ippTagOperation)
+	ippAttribute(&request, ippTagCharset, "attributes-charset", []byte("utf-8"))
+	ippAttribute(&request, ippTagNaturalLanguage, "attributes-natural-language", []byte("en"))
+	ippAttribute(&request, ippTagURI, "printer-uri", []byte(printerURI))
+	ippAttribute(&request, ippTagNameWithoutLang, "requesting-user-name", []byte("mlblg"))
+	ippAttribute(&request, ippTagNameWithoutLang, "job-name", []byte(jobName))
+	ippAttribute(&request, ippTagMimeMediaType, "document-format", []byte("application/pdf"))
+	request.WriteByte(ippTagJob)
+	ippAttribute(&request, ippTagInteger, "copies", ippInteger(copies))
+	if tray != "" {
+		// media-col is a collection holding just the media-source member
+		ippAttribute(&request, ippTagBegCollection, "media-col", nil)
+		ippAttribute(&request, ippTagMemberAttrName, "", []byte("media-source"))
+		ippAttribute(&request, ippTagKeyword, "", []byte(tray))
+		ippAttribute(&request, ippTagEndCollection, "", nil)
+	}
+	request.WriteByte(ippTagEnd)
+	request.Write(pdf)
+	return request.Bytes()
+}
+
+// IPP rides on HTTP, ipp:// and ipps:// are just http:// and https:// on
+// port 631.
+func ippHTTPURL(printerURI string) (string, error) {
+	parsed, err := url.Parse(printerURI)
+	if err != nil {
+		return "", err
+	}
+	switch parsed.Scheme {
+	case "ipp":
+		parsed.Scheme = "http"
+	case "ipps":
+		parsed.Scheme = "https"
+	case "http", "https":
+	default:
+		return "", fmt.Errorf("unsupported printer uri scheme %q", parsed.Scheme)
+	}
+	if parsed.Port() == "" {
+		parsed.Host = parsed.Host + ":" + ippDefaultPort
+	}
+	return parsed.String(), nil
+}
+
+func PrintToIPPPrinter(ctx context.Context, printerURI string, jobName string, copies int, tray string, pdf []byte) error {
+	httpURL, err := ippHTTPURL(printerURI)
+	if err != nil {
+		return err
+	}
+	// printer-uri has to use the ipp schemes even when given as http
+	printerURI = strings.Replace(strings.Replace(printerURI, "https://", "ipps://", 1), "http://", "ipp://", 1)
+	body := buildIPPPrintJob(printerURI, jobName, copies, tray, pdf)
+	ctx, cancel := context.WithTimeout(ctx, ippTimeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, httpURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/ipp")
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("printer answered %s", resp.Status)
+	}
+	// The response starts with the version and a status code, anything at
+	// 0x0100 or above is an error
+	header := make([]byte, 4)
+	_, err = io.ReadFull(resp.Body, header)
+	if err != nil {
+		return fmt.Errorf("short ipp response: %w", err)
+	}
+	status := binary.BigEndian.Uint16(header[2:])
+	if status >= 0x0100 {
+		return fmt.Errorf("print job refused with ipp status 0x%04x", status)
+	}
+	return nil
+}
+
+// Hands the pdf to CUPS through lp on stdin.
+func PrintWithLp(ctx context.Context, command string, printer string, jobName string, copies int, tray string, pdf []byte) error {
+	if command == "" {
+		command = "lp"
+	}
+	args := []string{"-n", strconv.Itoa(copies), "-t", jobName}
+	if printer != "" {
+		args = append(args, "-d", printer)
+	}
+	if tray != "" {
+		args = append(args, "-o", "InputSlot="+tray)
+	}
+	args = append(args, "-")
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Stdin = bytes.NewReader(pdf)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s failed: %w: %s", command, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// PrintPagePDF sends a page card to the configured printer, over IPP when
+// there's a printer URI and through lp otherwise.
+func PrintPagePDF(ctx context.Context, jobName string, pdf []byte, config ConfigData) error {
+	printer := config.PagePrinter
+	copies := printer.Copies
+	if copies < 1 {
+		copies = 1
+	}
+	if printer.IPPURL != "" {
+		return PrintToIPPPrinter(ctx, printer.IPPURL, jobName, copies, printer.Tray, pdf)
+	}
+	return PrintWithLp(ctx, printer.LpCommand, printer.Printer, jobName, copies, printer.Tray, pdf)
+}
+
+// Page printing is on once either an IPP URI or a CUPS queue is configured.
+func pagePrinterEnabled(config ConfigData) bool {
+	return config.PagePrinter.IPPURL != "" || config.PagePrinter.Printer != ""
+}
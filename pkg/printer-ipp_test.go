@@ -0,0 +1,171 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type ippTestAttribute struct {
+	Group byte
+	Tag   byte
+	Name  string
+	Value string
+}
+
+// Reads an IPP request the way a printer would, returning the operation,
+// every attribute with its group and the document data after them.
+func parseIPPRequest(t *testing.T, body []byte) (uint16, []ippTestAttribute, []byte) {
+	t.Helper()
+	if len(body) < 9 || body[0] != 1 || body[1] != 1 {
+		t.Fatalf("not an IPP/1.1 request: % x", body[:min(len(body), 9)])
+	}
+	operation := binary.BigEndian.Uint16(body[2:])
+	rest := body[8:]
+	var attributes []ippTestAttribute
+	var group byte
+	readString := func() string {
+		if len(rest) < 2 {
+			t.Fatal("truncated attribute")
+		}
+		length := int(binary.BigEndian.Uint16(rest))
+		if len(rest) < 2+length {
+			t.Fatal("truncated attribute")
+		}
+		value := string(rest[2 : 2+length])
+		rest = rest[2+length:]
+		return value
+	}
+	for {
+		if len(rest) == 0 {
+			t.Fatal("no end-of-attributes tag")
+		}
+		tag := rest[0]
+		rest = rest[1:]
+		if tag == ippTagEnd {
+			return operation, attributes, rest
+		}
+		// Tags below 0x10 start a new attribute group
+		if tag < 0x10 {
+			group = tag
+			continue
+		}
+		name := readString()
+		attributes = append(attributes, ippTestAttribute{group, tag, name, readString()})
+	}
+}
+
+func ippTestResponse(status uint16) []byte {
+	response := []byte{1, 1}
+	response = binary.BigEndian.AppendUint16(response, status)
+	response = binary.BigEndian.AppendUint32(response, 1)
+	return append(response, ippTagEnd)
+}
+
+func TestPrintToIPPPrinter(t *testing.T) {
+	var body []byte
+	var contentType string
+	status := uint16(0x0000)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		contentType = request.Header.Get("Content-Type")
+		body, _ = io.ReadAll(request.Body)
+		writer.Header().Set("Content-Type", "application/ipp")
+		writer.Write(ippTestResponse(status))
+	}))
+	defer server.Close()
+	pdf := []byte("%PDF-1.3\nlineup card\n%%EOF\n")
+	config := ConfigData{PagePrinter: PagePrinterConfig{IPPURL: server.URL + "/printers/Office", Copies: 2, Tray: "manual"}}
+
+	err := PrintPagePDF(context.Background(), "Twins @ Tigers", pdf, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType != "application/ipp" {
+		t.Errorf("sent as %q", contentType)
+	}
+	operation, attributes, document := parseIPPRequest(t, body)
+	if operation != ippOpPrintJob {
+		t.Errorf("operation 0x%04x, want Print-Job", operation)
+	}
+	printerURI := "ipp://" + strings.TrimPrefix(server.URL, "http://") + "/printers/Office"
+	want := []ippTestAttribute{
+		{ippTagOperation, ippTagCharset, "attributes-charset", "utf-8"},
+		{ippTagOperation, ippTagNaturalLanguage, "attributes-natural-language", "en"},
+		{ippTagOperation, ippTagURI, "printer-uri", printerURI},
+		{ippTagOperation, ippTagNameWithoutLang, "requesting-user-name", "mlblg"},
+		{ippTagOperation, ippTagNameWithoutLang, "job-name", "Twins @ Tigers"},
+		{ippTagOperation, ippTagMimeMediaType, "document-format", "application/pdf"},
+		{ippTagJob, ippTagInteger, "copies", "\x00\x00\x00\x02"},
+		{ippTagJob, ippTagBegCollection, "media-col", ""},
+		{ippTagJob, ippTagMemberAttrName, "", "media-source"},
+		{ippTagJob, ippTagKeyword, "", "manual"},
+		{ippTagJob, ippTagEndCollection, "", ""},
+	}
+	if !reflect.DeepEqual(attributes, want) {
+		t.Errorf("attributes\n%+v\nwant\n%+v", attributes, want)
+	}
+	if !bytes.Equal(document, pdf) {
+		t.Errorf("document data %q, want the pdf", document)
+	}
+
+	// No tray, no media-col
+	config.PagePrinter.Tray = ""
+	err = PrintPagePDF(context.Background(), "Twins @ Tigers", pdf, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, attributes, _ = parseIPPRequest(t, body)
+	if last := attributes[len(attributes)-1]; last.Name != "copies" {
+		t.Errorf("last attribute is %+v without a tray", last)
+	}
+
+	// client-error-document-format-not-supported
+	status = 0x040A
+	err = PrintPagePDF(context.Background(), "Twins @ Tigers", pdf, config)
+	if err == nil || !strings.Contains(err.Error(), "0x040a") {
+		t.Errorf("refused job came back as %v", err)
+	}
+}
+
+func TestPrintWithLp(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "lp")
+	err := os.WriteFile(script, []byte("#!/bin/sh\nprintf '%s\\n' \"$@\" > \""+dir+"/args\"\ncat > \""+dir+"/stdin\"\n"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdf := []byte("%PDF-1.3\nlineup card\n%%EOF\n")
+	config := ConfigData{PagePrinter: PagePrinterConfig{Printer: "Office", LpCommand: script, Copies: 2, Tray: "Tray2"}}
+	err = PrintPagePDF(context.Background(), "Twins @ Tigers", pdf, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, _ := os.ReadFile(filepath.Join(dir, "args"))
+	want := "-n\n2\n-t\nTwins @ Tigers\n-d\nOffice\n-o\nInputSlot=Tray2\n-\n"
+	if string(args) != want {
+		t.Errorf("lp args %q, want %q", args, want)
+	}
+	stdin, _ := os.ReadFile(filepath.Join(dir, "stdin"))
+	if !bytes.Equal(stdin, pdf) {
+		t.Errorf("lp got %q on stdin, want the pdf", stdin)
+	}
+
+	failing := filepath.Join(dir, "lp-failing")
+	err = os.WriteFile(failing, []byte("#!/bin/sh\necho 'lp: The printer or class does not exist.' >&2\nexit 1\n"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.PagePrinter.LpCommand = failing
+	err = PrintPagePDF(context.Background(), "Twins @ Tigers", pdf, config)
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("failing lp came back as %v", err)
+	}
+}
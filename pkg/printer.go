@@ -0,0 +1,25 @@
+package pkg
+
+import (
+	"bytes"
+	"net"
+	"time"
+)
+
+const RawPrinterPort = "9100"
+
+// Sends already rendered printer commands to a raw TCP (JetDirect) printer.
+// An address without a port gets the standard raw port 9100.
+func PrintToNetworkPrinter(address string, buf bytes.Buffer) error {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, RawPrinterPort)
+	}
+	conn, err := net.DialTimeout("tcp", address, 10*time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetWriteDeadline(time.Now().Add(30 * time.Second))
+	_, err = conn.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,109 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"path"
+
+	"golang.org/x/exp/slices"
+)
+
+const (
+	ReceiptPrefix = "receipt"
+	PagePrefix    = "page"
+)
+
+// Content types by file extension for reports copied around without being
+// rendered again.
+var reportContentTypes = map[string]string{
+	"pdf":  "application/pdf",
+	"html": "text/html",
+	"json": "application/json",
+	"md":   "text/markdown; charset=utf-8",
+	"txt":  "text/plain; charset=utf-8",
+}
+
+type reportArtifact struct {
+	Key         string
+	ContentType string
+	Render      func() bytes.Buffer
+}
+
+func ReceiptKey(filename string) string {
+	return path.Join(ReceiptPrefix, filename)
+}
+
+func PageKey(filename string) string {
+	return path.Join(PagePrefix, filename)
+}
+
+func reportArtifacts(report ReportData, config ConfigData) []reportArtifact {
+	artifacts := []reportArtifact{
+		{
+			Key:         ReceiptKey(report.Filename),
+			ContentType: "application/pdf",
+			Render:      func() bytes.Buffer { return GenerateReceiptPDF(report, config) },
+		},
+		{
+			Key:         PageKey(report.Filename),
+			ContentType: "application/pdf",
+			Render:      func() bytes.Buffer { return GeneratePagePDF(report, config) },
+		},
+		{
+			Key:         PageKey(HTMLFilename(report.Filename)),
+			ContentType: "text/html",
+			Render:      func() bytes.Buffer { return GeneratePageHTML(report, config) },
+		},
+		{
+			Key:         PageKey(JSONFilename(report.Filename)),
+			ContentType: "application/json",
+			Render:      func() bytes.Buffer { return GenerateLineupJSON(report, config) },
+		},
+	}
+	if slices.Contains(config.Formats, "markdown") {
+		artifacts = append(artifacts, reportArtifact{
+			Key:         PageKey(MarkdownFilename(report.Filename)),
+			ContentType: "text/markdown; charset=utf-8",
+			Render:      func() bytes.Buffer { return GenerateLineupMarkdown(report, config) },
+		})
+	}
+	if slices.Contains(config.Formats, "text") {
+		artifacts = append(artifacts, reportArtifact{
+			Key:         PageKey(TextFilename(report.Filename)),
+			ContentType: "text/plain; charset=utf-8",
+			Render:      func() bytes.Buffer { return GenerateLineupText(report, config) },
+		})
+	}
+	return artifacts
+}
+
+// ReportKeys lists every key PublishReport stores for a report.
+func ReportKeys(report ReportData, config ConfigData) []string {
+	var keys []string
+	for _, artifact := range reportArtifacts(report, config) {
+		keys = append(keys, artifact.Key)
+	}
+	return keys
+}
+
+// PublishReport renders and stores every output of a live report that is
+// not in storage yet, returning the keys that were written.
+func PublishReport(ctx context.Context, storage Storage, report ReportData, config ConfigData) ([]string, error) {
+	var published []string
+	for _, artifact := range reportArtifacts(report, config) {
+		exists, err := storage.Exists(ctx, artifact.Key)
+		if err != nil {
+			return published, err
+		}
+		if exists {
+			continue
+		}
+		data := artifact.Render()
+		err = storage.Put(ctx, artifact.Key, data.Bytes(), artifact.ContentType)
+		if err != nil {
+			return published, err
+		}
+		published = append(published, artifact.Key)
+	}
+	return published, nil
+}
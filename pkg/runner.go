@@ -0,0 +1,159 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How many game feeds get fetched at once.
+const lookupWorkers = 4
+
+// lookupReports runs lookup for 0..count-1 on at most lookupWorkers
+// goroutines, keeping the results in order. Lookups that never started
+// because ctx was cancelled come back as not OK, the ones in flight are
+// expected to give up on ctx themselves.
+func lookupReports(ctx context.Context, count int, lookup func(ind int) ReportData) []ReportData {
+	reports := make([]ReportData, count)
+	jobs := make(chan int)
+	var wait sync.WaitGroup
+	for range min(lookupWorkers, count) {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for ind := range jobs {
+				reports[ind] = lookup(ind)
+			}
+		}()
+	}
+feed:
+	for ind := range count {
+		// select picks at random when a worker is free too
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- ind:
+		}
+	}
+	close(jobs)
+	wait.Wait()
+	return reports
+}
+
+// Runner is the polling loop behind cmd/local -poll. Only one lookup runs
+// at a time and a tick that lands while the last one is still going is
+// skipped. What it has seen lives in the StateStore, which has its own lock.
+type Runner struct {
+	config  ConfigData
+	debug   bool
+	storage Storage
+	running sync.Mutex
+	state   *StateStore
+	// The day the calendars were last written
+	calendarDate string
+}
+
+func NewRunner(config ConfigData, debug bool, state *StateStore) *Runner {
+	return &Runner{
+		config:  config,
+		debug:   debug,
+		storage: NewFileStorage(config.ReportPath),
+		state:   state,
+	}
+}
+
+// Lookup runs one pass over today's games unless another pass is still
+// in flight.
+func (runner *Runner) Lookup(ctx context.Context) {
+	if !runner.running.TryLock() {
+		log.Println("Previous lookup still running, skipping this one")
+		return
+	}
+	defer runner.running.Unlock()
+	config := runner.config
+	data := GenerateFullReportContext(ctx, config, runner.debug)
+	var indexKeys []string
+	var indexed []ReportData
+	var announce []ReportData
+	var announceKeys [][]string
+	for _, report := range data {
+		matchup := strings.SplitN(report.Message, "\n", 2)[0]
+		firstSeen := runner.state.RecordCheck(report.GamePk, report.Filename, matchup, "")
+		if report.Live == true {
+			if runner.state.Published(report.GamePk) {
+				if runner.state.LineupChanged(report) {
+					fmt.Printf("\n Lineup changed for %s since its card was published\n", report.Filename)
+				}
+				continue
+			}
+			published, err := publishLocalReport(ctx, runner.storage, report, config)
+			runner.state.RecordPublished(report, err)
+			if len(published) > 0 {
+				announce = append(announce, report)
+				announceKeys = append(announceKeys, published)
+			}
+			if err == nil {
+				indexKeys = append(indexKeys, ReportKeys(report, config)...)
+				indexed = append(indexed, report)
+			}
+		} else if firstSeen {
+			fmt.Printf("\n Found %s - monitoring...\n", matchup)
+		} else {
+			fmt.Print(".")
+		}
+	}
+	err := PublishIndexPages(ctx, runner.storage, indexKeys, config)
+	if err != nil {
+		log.Println("Failed to update the index pages, error:", err)
+		// Not published until the manifest has them, the next lookup
+		// tries again
+		for _, report := range indexed {
+			runner.state.RecordPublished(report, err)
+		}
+	}
+	err = runner.state.Save()
+	if err != nil {
+		log.Println("Failed to save the watcher state, error:", err)
+	}
+	// The calendars only change with a new day or a new card
+	today := TodayDate()
+	if len(announce) > 0 || runner.calendarDate != today {
+		games := FindUpcomingGames(ctx, config, BaseLinksURL)
+		err = PublishCalendars(ctx, runner.storage, games, config)
+		if err != nil {
+			log.Println("Failed to update the calendars, error:", err)
+		} else if len(games) > 0 {
+			runner.calendarDate = today
+		}
+	}
+	for ind, report := range announce {
+		announceLocalReport(ctx, runner.storage, report, announceKeys[ind], config)
+	}
+}
+
+// Run looks up right away and then every interval until ctx is done,
+// waiting for the lookup in flight before returning.
+func (runner *Runner) Run(ctx context.Context, interval time.Duration) {
+	var wait sync.WaitGroup
+	defer wait.Wait()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			runner.Lookup(ctx)
+		}()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
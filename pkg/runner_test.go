@@ -0,0 +1,166 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLookupReportsOrder(t *testing.T) {
+	reports := lookupReports(context.Background(), 20, func(ind int) ReportData {
+		// Later games finish first
+		time.Sleep(time.Duration(20-ind) * time.Millisecond)
+		return ReportData{GamePk: ind, OK: true}
+	})
+	for ind, report := range reports {
+		if report.GamePk != ind || !report.OK {
+			t.Errorf("result %d is %+v", ind, report)
+		}
+	}
+}
+
+func TestLookupReportsWorkerBound(t *testing.T) {
+	var active, most atomic.Int32
+	lookupReports(context.Background(), 20, func(ind int) ReportData {
+		now := active.Add(1)
+		for {
+			seen := most.Load()
+			if now <= seen || most.CompareAndSwap(seen, now) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		active.Add(-1)
+		return ReportData{OK: true}
+	})
+	if most.Load() != lookupWorkers {
+		t.Errorf("%d lookups at once, want %d", most.Load(), lookupWorkers)
+	}
+}
+
+func TestLookupReportsCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var started atomic.Int32
+	go func() {
+		for started.Load() < lookupWorkers {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+	reports := lookupReports(ctx, 50, func(ind int) ReportData {
+		started.Add(1)
+		<-ctx.Done()
+		return ReportData{OK: true}
+	})
+	// The feed can hand out one more job as the cancel lands
+	if started.Load() > lookupWorkers+1 {
+		t.Errorf("%d lookups started after the cancel", started.Load())
+	}
+	skipped := 0
+	for _, report := range reports {
+		if !report.OK {
+			skipped++
+		}
+	}
+	if skipped != 50-int(started.Load()) {
+		t.Errorf("%d lookups came back not OK, want %d", skipped, 50-started.Load())
+	}
+}
+
+func TestGetURLBodyCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		<-request.Context().Done()
+	}))
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, ok := GetURLBody(ctx, server.URL)
+	if ok {
+		t.Error("a cancelled fetch came back OK")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("took %s to give up after the cancel", elapsed)
+	}
+}
+
+func TestRunnerLookupSkipsWhileRunning(t *testing.T) {
+	var mutex sync.Mutex
+	fetches := 0
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	original := GetURLBody
+	GetURLBody = func(ctx context.Context, targetURL string) ([]byte, bool) {
+		mutex.Lock()
+		fetches++
+		first := fetches == 1
+		mutex.Unlock()
+		if first {
+			close(entered)
+			<-release
+		}
+		return []byte(`{"dates": []}`), true
+	}
+	t.Cleanup(func() { GetURLBody = original })
+
+	dir := t.TempDir()
+	runner := NewRunner(ConfigData{ReportPath: dir, WatchTeams: AllTeams}, false,
+		LoadStateStore(filepath.Join(dir, StateFilename)))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runner.Lookup(context.Background())
+	}()
+	<-entered
+	runner.Lookup(context.Background())
+	mutex.Lock()
+	skipped := fetches == 1
+	mutex.Unlock()
+	if !skipped {
+		t.Error("a second lookup ran while the first was in flight")
+	}
+	close(release)
+	<-done
+}
+
+func TestRunnerLookupCalendars(t *testing.T) {
+	calendarFetches := 0
+	original := GetURLBody
+	GetURLBody = func(ctx context.Context, targetURL string) ([]byte, bool) {
+		if strings.Contains(targetURL, "startDate=") {
+			calendarFetches++
+			return []byte(testSchedule), true
+		}
+		// No games today, nothing gets published
+		return []byte(`{"dates": []}`), true
+	}
+	t.Cleanup(func() { GetURLBody = original })
+
+	dir := t.TempDir()
+	runner := NewRunner(ConfigData{ReportPath: dir, WatchTeams: AllTeams}, false,
+		LoadStateStore(filepath.Join(dir, StateFilename)))
+	runner.Lookup(context.Background())
+	if calendarFetches != 1 {
+		t.Fatalf("first lookup of the day fetched the calendar schedule %d times", calendarFetches)
+	}
+	if _, err := os.Stat(filepath.Join(dir, CalendarKey)); err != nil {
+		t.Error("no calendar written on the first lookup:", err)
+	}
+	runner.Lookup(context.Background())
+	if calendarFetches != 1 {
+		t.Error("calendars rebuilt on a lookup that published nothing")
+	}
+	runner.calendarDate = "2025-08-10"
+	runner.Lookup(context.Background())
+	if calendarFetches != 2 {
+		t.Error("calendars not rebuilt on a new day")
+	}
+}
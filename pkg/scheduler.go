@@ -0,0 +1,296 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Lineups come out a few hours before first pitch. Checks start checkLeadTime
+// ahead and back off from checkFirstInterval to checkMaxInterval while
+// nothing is posted, except close to first pitch where they stay tight.
+// Past checkLateAfter a game is likely in a rain delay or the second half
+// of a doubleheader, and gets checked every checkMaxInterval until it
+// starts or is called off.
+const (
+	checkLeadTime      = 3 * time.Hour
+	checkFirstInterval = 2 * time.Minute
+	checkMaxInterval   = 15 * time.Minute
+	checkNearStart     = 45 * time.Minute
+	checkLateAfter     = 90 * time.Minute
+	scheduleRetry      = 5 * time.Minute
+)
+
+// Where the Lambda keeps the plan between invocations.
+const SchedulePlanKey = "scheduler.json"
+
+// Games in these states never get a lineup card.
+var finishedGameStates = []string{"Final", "Game Over", "Completed Early", "Postponed", "Cancelled", "Suspended"}
+
+func BuildSchedulePlan(date string, games []Game) SchedulePlan {
+	plan := SchedulePlan{Date: date}
+	for _, game := range games {
+		start, err := time.Parse(time.RFC3339, game.GameDate)
+		if err != nil {
+			log.Println("Skipping game", game.GamePk, "with bad start time", game.GameDate)
+			continue
+		}
+		check := ScheduledCheck{
+			GamePk:    game.GamePk,
+			Matchup:   fmt.Sprintf("%s @ %s", game.Teams.Away.Team.Name, game.Teams.Home.Team.Name),
+			Start:     start,
+			NextCheck: start.Add(-checkLeadTime),
+		}
+		for _, state := range finishedGameStates {
+			if game.Status.DetailedState == state {
+				check.Done = true
+			}
+		}
+		plan.Checks = append(plan.Checks, check)
+	}
+	return plan
+}
+
+// NextWake is the earliest pending check, zero once every game is done.
+func (plan SchedulePlan) NextWake() time.Time {
+	var wake time.Time
+	for _, check := range plan.Checks {
+		if !check.Done && (wake.IsZero() || check.NextCheck.Before(wake)) {
+			wake = check.NextCheck
+		}
+	}
+	return wake
+}
+
+// Pushes a check back after a lookup that found no lineups yet.
+func rescheduleCheck(check *ScheduledCheck, now time.Time) {
+	check.Interval *= 2
+	if check.Interval < checkFirstInterval {
+		check.Interval = checkFirstInterval
+	}
+	if check.Interval > checkMaxInterval {
+		check.Interval = checkMaxInterval
+	}
+	if check.Start.Sub(now) < checkNearStart {
+		check.Interval = checkFirstInterval
+	}
+	if now.After(check.Start.Add(checkLateAfter)) {
+		check.Interval = checkMaxInterval
+	}
+	check.NextCheck = now.Add(check.Interval)
+}
+
+// MarkDone closes the check for a game once its card is published.
+func (plan *SchedulePlan) MarkDone(gamePk int) {
+	for ind := range plan.Checks {
+		if plan.Checks[ind].GamePk == gamePk {
+			plan.Checks[ind].Done = true
+		}
+	}
+}
+
+// RunDueChecks looks up every game whose check is due, a few at a time,
+// and returns the reports that went live with one standings lookup shared
+// between them. Those stay due again shortly until the caller publishes
+// them and calls MarkDone, so a failed publish gets another try. When the
+// standings can't be looked up none of them are returned, the cards wait
+// for the next check rather than going out without standings. The rest get
+// rescheduled. Every lookup is recorded in state unless it's nil.
+func RunDueChecks(ctx context.Context, plan *SchedulePlan, now time.Time, debug bool, state *StateStore) []ReportData {
+	var liveReports []ReportData
+	var due []*ScheduledCheck
+	for ind := range plan.Checks {
+		check := &plan.Checks[ind]
+		if !check.Done && !check.NextCheck.After(now) {
+			due = append(due, check)
+		}
+	}
+	reports := lookupReports(ctx, len(due), func(ind int) ReportData {
+		return lookupGameReport(ctx, due[ind].GamePk, debug)
+	})
+	var liveChecks []*ScheduledCheck
+	for ind, report := range reports {
+		check := due[ind]
+		if report.OK && report.Live {
+			// Recorded once the standings are in
+			liveReports = append(liveReports, report)
+			liveChecks = append(liveChecks, check)
+			check.NextCheck = now.Add(checkFirstInterval)
+			continue
+		}
+		if state != nil {
+			lookupErr := ""
+			if !report.OK {
+				lookupErr = "game lookup failed"
+			}
+			state.RecordCheck(check.GamePk, report.Filename, check.Matchup, lookupErr)
+		}
+		if !report.OK {
+			// A failed lookup says nothing about the lineups, try again soon
+			check.NextCheck = now.Add(checkFirstInterval)
+			continue
+		}
+		if report.GameData.Status.AbstractGameState == "Final" {
+			// Postponed or called off before any lineups came out
+			log.Println("Done with", check.Matchup, "- the game is over without lineups")
+			check.Done = true
+			continue
+		}
+		rescheduleCheck(check, now)
+		log.Println("No lineups yet for", check.Matchup, "- next check at", check.NextCheck.Local().Format(time.Kitchen))
+	}
+	if len(liveReports) == 0 {
+		return liveReports
+	}
+	standings := GenerateStandings(ctx)
+	if state != nil {
+		lookupErr := ""
+		if !standings.OK {
+			lookupErr = "standings lookup failed"
+		}
+		for ind, report := range liveReports {
+			state.RecordCheck(report.GamePk, report.Filename, liveChecks[ind].Matchup, lookupErr)
+		}
+	}
+	if !standings.OK {
+		log.Println("No standings, holding", len(liveReports), "cards until the next check")
+		return nil
+	}
+	for ind := range liveReports {
+		liveReports[ind] = withStandings(liveReports[ind], standings)
+	}
+	return liveReports
+}
+
+// Returns the plan for today, building a new one from the schedule when
+// the day has changed. A day without games gets an empty plan so the
+// schedule isn't fetched again until tomorrow. ok is false when the
+// schedule couldn't be loaded.
+func RefreshSchedulePlan(ctx context.Context, plan SchedulePlan, config ConfigData) (SchedulePlan, bool) {
+	today := TodayDate()
+	if plan.Date == today {
+		return plan, true
+	}
+	games, err := findScheduledGames(ctx, config, BaseLinksURL, today, today)
+	if err != nil {
+		log.Println(err)
+		return plan, false
+	}
+	log.Println("Loaded", len(games), "games for", today)
+	return BuildSchedulePlan(today, games), true
+}
+
+// The schedule for the next day gets loaded a little after midnight US
+// eastern time.
+func nextScheduleDay() time.Time {
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		location = time.UTC
+	}
+	now := time.Now().In(location)
+	return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 5, 0, 0, location)
+}
+
+// RunScheduler replaces the one-minute polling in cmd/local: it loads the
+// day's games once, looks at each of them around its first pitch and
+// sleeps in between. The plan is kept in state so a restart picks up where
+// the last run left off.
+func RunScheduler(ctx context.Context, debug bool, config ConfigData, state *StateStore) {
+	storage := NewFileStorage(config.ReportPath)
+	plan := state.Plan()
+	for {
+		var ok bool
+		var wake time.Time
+		newDay := plan.Date != TodayDate()
+		plan, ok = RefreshSchedulePlan(ctx, plan, config)
+		if !ok {
+			wake = time.Now().Add(scheduleRetry)
+		} else {
+			if newDay {
+				err := PublishCalendars(ctx, storage, FindUpcomingGames(ctx, config, BaseLinksURL), config)
+				if err != nil {
+					log.Println("Failed to update the calendars, error:", err)
+				}
+			}
+			var publishedKeys []string
+			var indexKeys []string
+			var indexed []ReportData
+			reports := RunDueChecks(ctx, &plan, time.Now(), debug, state)
+			publishedReports := make([][]string, len(reports))
+			for ind, report := range reports {
+				published, err := publishLocalReport(ctx, storage, report, config)
+				publishedReports[ind] = published
+				publishedKeys = append(publishedKeys, published...)
+				state.RecordPublished(report, err)
+				if err == nil {
+					indexKeys = append(indexKeys, ReportKeys(report, config)...)
+					indexed = append(indexed, report)
+				}
+			}
+			// Games are only done once the manifest knows their cards
+			err := PublishIndexPages(ctx, storage, indexKeys, config)
+			for _, report := range indexed {
+				if err != nil {
+					state.RecordPublished(report, err)
+				} else {
+					plan.MarkDone(report.GamePk)
+				}
+			}
+			if err != nil {
+				log.Println("Failed to update the index pages, error:", err)
+			}
+			state.SetPlan(plan)
+			err = state.Save()
+			if err != nil {
+				log.Println("Failed to save the watcher state, error:", err)
+			}
+			if len(publishedKeys) > 0 {
+				err = PublishCalendars(ctx, storage, FindUpcomingGames(ctx, config, BaseLinksURL), config)
+				if err != nil {
+					log.Println("Failed to update the calendars, error:", err)
+				}
+			}
+			for ind, report := range reports {
+				announceLocalReport(ctx, storage, report, publishedReports[ind], config)
+			}
+			wake = plan.NextWake()
+			if wake.IsZero() {
+				wake = nextScheduleDay()
+			}
+		}
+		log.Println("Sleeping until", wake.Local().Format(time.DateTime))
+		timer := time.NewTimer(time.Until(wake))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// LoadSchedulePlan reads the plan the last run left in storage, an empty
+// plan when there is none.
+func LoadSchedulePlan(ctx context.Context, storage Storage) (SchedulePlan, error) {
+	var plan SchedulePlan
+	data, err := storage.Get(ctx, SchedulePlanKey)
+	if errors.Is(err, ErrNotFound) {
+		return plan, nil
+	}
+	if err != nil {
+		return plan, err
+	}
+	err = json.Unmarshal(data, &plan)
+	return plan, err
+}
+
+func SaveSchedulePlan(ctx context.Context, storage Storage, plan SchedulePlan) error {
+	data, err := json.MarshalIndent(plan, "", "    ")
+	if err != nil {
+		return err
+	}
+	return storage.Put(ctx, SchedulePlanKey, data, "application/json")
+}
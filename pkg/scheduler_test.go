@@ -0,0 +1,164 @@
+package pkg
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRescheduleCheck(t *testing.T) {
+	start := time.Date(2025, 8, 11, 23, 10, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		now      time.Time
+		interval time.Duration
+		want     time.Duration
+	}{
+		{"first miss", start.Add(-3 * time.Hour), 0, checkFirstInterval},
+		{"backing off", start.Add(-2 * time.Hour), 4 * time.Minute, 8 * time.Minute},
+		{"capped", start.Add(-2 * time.Hour), 12 * time.Minute, checkMaxInterval},
+		{"near first pitch", start.Add(-30 * time.Minute), checkMaxInterval, checkFirstInterval},
+		{"just started", start.Add(time.Hour), checkFirstInterval, checkFirstInterval},
+		{"rain delay", start.Add(2 * time.Hour), checkFirstInterval, checkMaxInterval},
+		{"late doubleheader", start.Add(5 * time.Hour), checkMaxInterval, checkMaxInterval},
+	}
+	for _, test := range tests {
+		check := ScheduledCheck{GamePk: 776543, Start: start, Interval: test.interval}
+		rescheduleCheck(&check, test.now)
+		if check.Done {
+			t.Errorf("%s: gave up on the game", test.name)
+		}
+		if check.Interval != test.want || !check.NextCheck.Equal(test.now.Add(test.want)) {
+			t.Errorf("%s: next check in %s, want %s", test.name, check.Interval, test.want)
+		}
+	}
+}
+
+func TestSchedulePlan(t *testing.T) {
+	var games []Game
+	for ind, gameDate := range []string{"2025-08-11T23:10:00Z", "2025-08-11T17:05:00Z", "2025-08-11T20:00:00Z"} {
+		game := Game{GamePk: 776543 + ind, GameDate: gameDate}
+		game.Status.DetailedState = "Scheduled"
+		games = append(games, game)
+	}
+	games[2].Status.DetailedState = "Postponed"
+	plan := BuildSchedulePlan("2025-08-11", games)
+	if len(plan.Checks) != 3 || !plan.Checks[2].Done {
+		t.Fatalf("plan %+v", plan)
+	}
+	want := time.Date(2025, 8, 11, 14, 5, 0, 0, time.UTC)
+	if wake := plan.NextWake(); !wake.Equal(want) {
+		t.Errorf("wakes at %s, want %s", wake, want)
+	}
+	plan.MarkDone(776544)
+	want = time.Date(2025, 8, 11, 20, 10, 0, 0, time.UTC)
+	if wake := plan.NextWake(); !wake.Equal(want) {
+		t.Errorf("wakes at %s after the day game is done, want %s", wake, want)
+	}
+	plan.MarkDone(776543)
+	if wake := plan.NextWake(); !wake.IsZero() {
+		t.Errorf("wakes at %s with every game done", wake)
+	}
+}
+
+func TestRefreshSchedulePlan(t *testing.T) {
+	config := ConfigData{WatchTeams: AllTeams}
+	stubURLBody(t, `{"dates": []}`, true)
+	plan, ok := RefreshSchedulePlan(context.Background(), SchedulePlan{Date: "2025-08-10"}, config)
+	if !ok {
+		t.Fatal("an off day counted as a failed lookup")
+	}
+	if plan.Date != TodayDate() || len(plan.Checks) != 0 || !plan.NextWake().IsZero() {
+		t.Errorf("off day plan %+v", plan)
+	}
+
+	// The stored empty plan is kept without asking for the schedule again
+	stubURLBody(t, "", false)
+	plan, ok = RefreshSchedulePlan(context.Background(), plan, config)
+	if !ok || plan.Date != TodayDate() {
+		t.Errorf("refetched the schedule for a day without games")
+	}
+
+	_, ok = RefreshSchedulePlan(context.Background(), SchedulePlan{Date: "2025-08-10"}, config)
+	if ok {
+		t.Error("a failed lookup counted as a loaded schedule")
+	}
+}
+
+const testLiveFeed = `{"gameData": {
+	"status": {"abstractGameState": "Live"},
+	"datetime": {"officialDate": "2025-08-11", "time": "7:10", "ampm": "PM"},
+	"teams": {"away": {"name": "Minnesota Twins"}, "home": {"name": "Detroit Tigers"}}},
+ "liveData": {"boxscore": {"teams": {
+	"away": {"team": {"name": "Minnesota Twins"}, "pitchers": [100], "bullpen": [101]},
+	"home": {"team": {"name": "Detroit Tigers"}, "pitchers": [200], "bullpen": [201]}}}}}`
+
+const testPeople = `{"people": [
+	{"id": 100, "fullName": "Joe Ryan", "primaryNumber": "41", "pitchHand": {"code": "R"}},
+	{"id": 101, "fullName": "Jhoan Duran", "primaryNumber": "59", "pitchHand": {"code": "R"}},
+	{"id": 200, "fullName": "Tarik Skubal", "primaryNumber": "29", "pitchHand": {"code": "L"}},
+	{"id": 201, "fullName": "Will Vest", "primaryNumber": "19", "pitchHand": {"code": "R"}}]}`
+
+const testStandings = `{"structure": {"sports": [{"leagues": []}]}, "records": []}`
+
+// Answers statsapi requests by the first part of the URL they contain, a
+// missing body fails the request. Returns how often each part was asked for.
+func stubURLBodies(t *testing.T, bodies map[string]string) map[string]int {
+	var mutex sync.Mutex
+	calls := make(map[string]int)
+	original := GetURLBody
+	GetURLBody = func(ctx context.Context, targetURL string) ([]byte, bool) {
+		for part, body := range bodies {
+			if strings.Contains(targetURL, part) {
+				mutex.Lock()
+				calls[part]++
+				mutex.Unlock()
+				return []byte(body), body != ""
+			}
+		}
+		t.Errorf("unexpected fetch of %s", targetURL)
+		return []byte{}, false
+	}
+	t.Cleanup(func() { GetURLBody = original })
+	return calls
+}
+
+func TestRunDueChecksStandings(t *testing.T) {
+	now := time.Date(2025, 8, 11, 22, 0, 0, 0, time.UTC)
+	plan := SchedulePlan{Date: "2025-08-11"}
+	for _, gamePk := range []int{776543, 776544} {
+		plan.Checks = append(plan.Checks, ScheduledCheck{GamePk: gamePk, Matchup: "Twins @ Tigers", Start: now.Add(time.Hour), NextCheck: now})
+	}
+	state := LoadStateStore(filepath.Join(t.TempDir(), StateFilename))
+
+	calls := stubURLBodies(t, map[string]string{"/feed/live": testLiveFeed, "/people": testPeople, "standings": ""})
+	reports := RunDueChecks(context.Background(), &plan, now, false, state)
+	if len(reports) != 0 {
+		t.Errorf("%d cards came back without standings", len(reports))
+	}
+	if calls["standings"] != 1 {
+		t.Errorf("standings looked up %d times in one pass", calls["standings"])
+	}
+	for _, check := range plan.Checks {
+		if check.Done || !check.NextCheck.Equal(now.Add(checkFirstInterval)) {
+			t.Errorf("check %+v isn't due again shortly", check)
+		}
+	}
+
+	calls = stubURLBodies(t, map[string]string{"/feed/live": testLiveFeed, "/people": testPeople, "standings": testStandings})
+	reports = RunDueChecks(context.Background(), &plan, now.Add(checkFirstInterval), false, state)
+	if len(reports) != 2 {
+		t.Fatalf("%d cards once the standings came back, want 2", len(reports))
+	}
+	if calls["standings"] != 1 {
+		t.Errorf("standings looked up %d times in one pass", calls["standings"])
+	}
+	for _, report := range reports {
+		if !report.Standings.OK || !strings.Contains(report.PageData, "- AL West -") {
+			t.Errorf("card for %d has no standings", report.GamePk)
+		}
+	}
+}
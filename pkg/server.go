@@ -0,0 +1,178 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+var serveTemplate = template.Must(template.ParseFS(templateFS, "templates/serve.html"))
+
+type serveArtifact struct {
+	ContentType string
+	Render      func(report ReportData, config ConfigData) bytes.Buffer
+	Filename    func(filename string) string
+}
+
+func samePDFName(filename string) string {
+	return filename
+}
+
+// The cards /game/{pk}/{artifact} can generate, in the order they're linked.
+var serveArtifactNames = []string{"page.pdf", "receipt.pdf", "page.html", "page.json", "page.md", "page.txt"}
+
+var serveArtifacts = map[string]serveArtifact{
+	"page.pdf":    {"application/pdf", GeneratePagePDF, samePDFName},
+	"receipt.pdf": {"application/pdf", GenerateReceiptPDF, samePDFName},
+	"page.html":   {"text/html; charset=utf-8", GeneratePageHTML, HTMLFilename},
+	"page.json":   {"application/json", GenerateLineupJSON, JSONFilename},
+	"page.md":     {"text/markdown; charset=utf-8", GenerateLineupMarkdown, MarkdownFilename},
+	"page.txt":    {"text/plain; charset=utf-8", GenerateLineupText, TextFilename},
+}
+
+type serveIndexData struct {
+	Date      string
+	Games     []Game
+	Artifacts []string
+}
+
+// GenerateGameReport builds the report for a single game on demand, with
+// standings when they can be looked up.
+func GenerateGameReport(ctx context.Context, gamePk int, debug bool) ReportData {
+	report := lookupGameReport(ctx, gamePk, debug)
+	if !report.OK || !report.Live {
+		return report
+	}
+	standings := GenerateStandings(ctx)
+	if standings.OK {
+		report = withStandings(report, standings)
+	}
+	return report
+}
+
+// Looks a single game up without standings.
+func lookupGameReport(ctx context.Context, gamePk int, debug bool) ReportData {
+	link := GameLink{
+		Matchup: fmt.Sprintf("Game %d", gamePk),
+		Link:    fmt.Sprintf("%s/api/v1.1/game/%d/feed/live", BaseLinksURL, gamePk),
+		PK:      gamePk,
+	}
+	report := GeneratePreGameReport(ctx, link, debug)
+	if !report.OK || !report.Live {
+		return report
+	}
+	game := report.GameData
+	report.Filename = ReportFilename(game.Datetime.OfficialDate,
+		game.Teams.Away.Name, game.Teams.Home.Name, gamePk)
+	return report
+}
+
+func serveIndex(config ConfigData) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		var page bytes.Buffer
+		data := serveIndexData{
+			Date:      TodayDate(),
+			Games:     FindTodayGames(request.Context(), config, BaseLinksURL),
+			Artifacts: serveArtifactNames,
+		}
+		err := serveTemplate.Execute(&page, data)
+		if err != nil {
+			log.Println("Failed to render the game list, error:", err)
+			http.Error(writer, "failed to render the game list", http.StatusInternalServerError)
+			return
+		}
+		writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+		writer.Write(page.Bytes())
+	}
+}
+
+func serveGame(config ConfigData, debug bool) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		gamePk, err := strconv.Atoi(request.PathValue("pk"))
+		if err != nil || gamePk <= 0 {
+			http.Error(writer, "bad game pk", http.StatusBadRequest)
+			return
+		}
+		artifact, ok := serveArtifacts[request.PathValue("artifact")]
+		if !ok {
+			http.NotFound(writer, request)
+			return
+		}
+		report := GenerateGameReport(request.Context(), gamePk, debug)
+		if !report.OK {
+			http.Error(writer, "couldn't look up the game", http.StatusBadGateway)
+			return
+		}
+		if !report.Live {
+			// Lineups only show up once the game is close to starting
+			http.Error(writer, "lineups aren't out yet\n"+report.Message, http.StatusNotFound)
+			return
+		}
+		data := artifact.Render(report, config)
+		writer.Header().Set("Content-Type", artifact.ContentType)
+		writer.Header().Set("Content-Disposition",
+			fmt.Sprintf("inline; filename=%q", artifact.Filename(report.Filename)))
+		writer.Write(data.Bytes())
+	}
+}
+
+// What /files/ serves out of ReportPath. That defaults to the working
+// directory, so anything that isn't a published card or index stays out.
+var (
+	serveFilePrefixes = []string{PagePrefix + "/", ReceiptPrefix + "/", TeamIndexDir + "/"}
+	serveFileKeys     = []string{GamesIndexKey, FeedKey, CalendarKey, "page.html", "receipt.html"}
+)
+
+func serveFiles(config ConfigData) http.Handler {
+	fileServer := http.StripPrefix("/files/", http.FileServer(http.Dir(config.ReportPath)))
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		// Checked the way the file server will resolve it
+		key := strings.TrimPrefix(path.Clean(request.URL.Path), "/files/")
+		allowed := slices.Contains(serveFileKeys, key)
+		for _, prefix := range serveFilePrefixes {
+			allowed = allowed || strings.HasPrefix(key+"/", prefix)
+		}
+		if !allowed {
+			http.NotFound(writer, request)
+			return
+		}
+		fileServer.ServeHTTP(writer, request)
+	})
+}
+
+// NewServeMux wires up the game list at /, the published cards and indexes
+// under /files/, on-demand cards at /game/{pk}/{artifact} and the JSON API under
+// /v1/.
+func NewServeMux(config ConfigData, debug bool) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", serveIndex(config))
+	mux.Handle("GET /files/", serveFiles(config))
+	mux.HandleFunc("GET /game/{pk}/{artifact}", serveGame(config, debug))
+	registerAPI(mux, config, debug)
+	return mux
+}
+
+func RunServe(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addrPtr := flags.String("addr", "localhost:8080", "Address to listen on")
+	debugPtr := flags.Bool("debug", false, "Enable debug output")
+	flags.Parse(args)
+	config := GetOrHandleConfiguration()
+	server := &http.Server{
+		Addr:              *addrPtr,
+		Handler:           NewServeMux(config, *debugPtr),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Println("Serving on", *addrPtr)
+	log.Fatal(server.ListenAndServe())
+}
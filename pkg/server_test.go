@@ -0,0 +1,74 @@
+package pkg
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestServeFiles(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{
+		"page/2025-08-11-Minnesota-Twins-at-Detroit-Tigers-776543.pdf",
+		"receipt/2025-08-11-Minnesota-Twins-at-Detroit-Tigers-776543.pdf",
+		"team/detroit-tigers.html",
+		"games.html",
+		"feed.xml",
+		"state.json",
+		"config.json",
+		"secrets/key.pem",
+	} {
+		filename := filepath.Join(root, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(filename), 0755)
+		err := os.WriteFile(filename, []byte(name), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	mux := NewServeMux(ConfigData{ReportPath: root}, false)
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/files/page/2025-08-11-Minnesota-Twins-at-Detroit-Tigers-776543.pdf", http.StatusOK},
+		{"/files/receipt/2025-08-11-Minnesota-Twins-at-Detroit-Tigers-776543.pdf", http.StatusOK},
+		{"/files/team/detroit-tigers.html", http.StatusOK},
+		{"/files/games.html", http.StatusOK},
+		{"/files/feed.xml", http.StatusOK},
+		{"/files/page/", http.StatusOK},
+		{"/files/", http.StatusNotFound},
+		{"/files/state.json", http.StatusNotFound},
+		{"/files/config.json", http.StatusNotFound},
+		{"/files/secrets/key.pem", http.StatusNotFound},
+		{"/files/page/../state.json", http.StatusNotFound},
+		{"/files/page/..%2fstate.json", http.StatusNotFound},
+	}
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, httptest.NewRequest("GET", test.path, nil))
+		// The mux answers unclean paths with a redirect to the clean one
+		if recorder.Code == http.StatusMovedPermanently || recorder.Code == http.StatusTemporaryRedirect {
+			location := recorder.Header().Get("Location")
+			recorder = httptest.NewRecorder()
+			mux.ServeHTTP(recorder, httptest.NewRequest("GET", location, nil))
+		}
+		if recorder.Code != test.want {
+			t.Errorf("GET %s = %d, want %d", test.path, recorder.Code, test.want)
+		}
+	}
+}
+
+func TestServeGameBadFeed(t *testing.T) {
+	// A CDN error page instead of the live feed
+	stubURLBody(t, "<html><body>503 Service Unavailable</body></html>", true)
+	mux := NewServeMux(ConfigData{}, false)
+	for _, path := range []string{"/game/776543/page.pdf", APIPrefix + "/games/776543/lineup"} {
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+		if recorder.Code != http.StatusBadGateway {
+			t.Errorf("GET %s = %d, want 502", path, recorder.Code)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"html/template"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+const NotFoundKey = "404.html"
+
+// The hand written landing page and its images
+//
+//go:embed site
+var siteFS embed.FS
+
+var notFoundTemplate = template.Must(template.ParseFS(templateFS, "templates/404.html"))
+
+var cardTemplate = template.Must(template.ParseFS(templateFS, "templates/card.html"))
+
+type cardPageData struct {
+	Game PublishedGame
+	Root string
+	Page string
+}
+
+var siteContentTypes = map[string]string{
+	"html": "text/html",
+	"gif":  "image/gif",
+	"png":  "image/png",
+	"css":  "text/css",
+}
+
+// The 404 page can show up at any depth, so its links need to be absolute.
+func GenerateNotFoundPage(siteURL string) (bytes.Buffer, error) {
+	var retBuff bytes.Buffer
+	root := "/"
+	if siteURL != "" {
+		root = strings.TrimSuffix(siteURL, "/") + "/"
+	}
+	err := notFoundTemplate.Execute(&retBuff, root)
+	return retBuff, err
+}
+
+// GenerateCardPage builds the html page for an archived card that predates
+// the html reports, showing its page PDF and linking every other format.
+// It is stored under page/ like a rendered card.
+func GenerateCardPage(game PublishedGame) (bytes.Buffer, error) {
+	var retBuff bytes.Buffer
+	data := cardPageData{Game: withRoot([]PublishedGame{game}, "../")[0], Root: "../"}
+	for _, link := range data.Game.Links {
+		if link.Label == "PAGE" {
+			data.Page = link.Href
+		}
+	}
+	err := cardTemplate.Execute(&retBuff, data)
+	return retBuff, err
+}
+
+// Adds an html page for every archived card without one.
+func publishCardPages(ctx context.Context, out Storage, keys []string) ([]string, error) {
+	var published []string
+	for _, game := range CollectPublishedGames(keys) {
+		if slices.ContainsFunc(game.Links, func(link PublishedLink) bool { return link.Label == "HTML" }) {
+			continue
+		}
+		page, err := GenerateCardPage(game)
+		if err != nil {
+			return published, err
+		}
+		key := PageKey(game.Name + ".html")
+		err = out.Put(ctx, key, page.Bytes(), "text/html")
+		if err != nil {
+			return published, err
+		}
+		published = append(published, key)
+	}
+	return published, nil
+}
+
+func publishSiteAssets(ctx context.Context, out Storage) error {
+	return fs.WalkDir(siteFS, "site", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		data, err := siteFS.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		key := strings.TrimPrefix(name, "site/")
+		return out.Put(ctx, key, data, siteContentTypes[strings.TrimPrefix(path.Ext(key), ".")])
+	})
+}
+
+// BuildSite renders the whole static site from a report archive into out:
+// the landing page, every published report plus an html page for cards
+// archived without one, the game and team indexes, the feeds, the
+// calendars, the manifest and 404.html. archive and out can be the same
+// storage to rebuild a site in place.
+func BuildSite(ctx context.Context, archive Storage, out Storage, config ConfigData) error {
+	var keys []string
+	for _, prefix := range []string{PagePrefix + "/", ReceiptPrefix + "/"} {
+		found, err := archive.List(ctx, prefix)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, found...)
+	}
+	if archive != out {
+		for _, key := range keys {
+			data, err := archive.Get(ctx, key)
+			if err != nil {
+				return err
+			}
+			err = out.Put(ctx, key, data, reportContentTypes[strings.TrimPrefix(path.Ext(key), ".")])
+			if err != nil {
+				return err
+			}
+		}
+	}
+	cardPages, err := publishCardPages(ctx, out, keys)
+	if err != nil {
+		return err
+	}
+	keys = append(keys, cardPages...)
+	// Keep the publish times from the archive's manifest when it has one
+	manifest, err := LoadManifest(ctx, archive)
+	if err != nil {
+		return err
+	}
+	manifest = addToManifest(manifest, keys, time.Now().UTC().Format(time.RFC3339))
+	err = SaveManifest(ctx, out, manifest)
+	if err != nil {
+		return err
+	}
+	games := CollectPublishedGames(ManifestKeys(manifest))
+	err = publishGameIndexes(ctx, out, games, ManifestPublished(manifest), config.SiteURL)
+	if err != nil {
+		return err
+	}
+	err = PublishCalendars(ctx, out, FindUpcomingGames(ctx, config, BaseLinksURL), config)
+	if err != nil {
+		return err
+	}
+	err = publishSiteAssets(ctx, out)
+	if err != nil {
+		return err
+	}
+	notFound, err := GenerateNotFoundPage(config.SiteURL)
+	if err != nil {
+		return err
+	}
+	return out.Put(ctx, NotFoundKey, notFound.Bytes(), "text/html")
+}
@@ -0,0 +1,82 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestBuildSite(t *testing.T) {
+	ctx := context.Background()
+	stubURLBody(t, testSchedule, true)
+	config := ConfigData{WatchTeams: []string{"Minnesota Twins", "New York Mets"}}
+	archive := NewMemoryStorage()
+	report := testReport(776543, "Minnesota Twins", "Detroit Tigers")
+	_, err := PublishReport(ctx, archive, report, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A card from before the html reports, only the two PDFs
+	oldName := "2025-04-02-New-York-Mets-at-Philadelphia-Phillies-745001"
+	for _, key := range []string{PageKey(oldName + ".pdf"), ReceiptKey(oldName + ".pdf")} {
+		err = archive.Put(ctx, key, []byte("%PDF-1.3"), "application/pdf")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := NewMemoryStorage()
+	err = BuildSite(ctx, archive, out, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page, contentType, ok := out.Object(PageKey(oldName + ".html"))
+	if !ok || contentType != "text/html" {
+		t.Fatalf("no html page for the old card, %q", contentType)
+	}
+	for _, href := range []string{`data="../page/` + oldName + `.pdf"`, `href="../receipt/` + oldName + `.pdf"`, `href="../games.html"`} {
+		if !bytes.Contains(page, []byte(href)) {
+			t.Errorf("old card page is missing %s", href)
+		}
+	}
+	rendered, _, _ := archive.Object(PageKey(HTMLFilename(report.Filename)))
+	if built, _, _ := out.Object(PageKey(HTMLFilename(report.Filename))); !bytes.Equal(built, rendered) {
+		t.Error("the archived html card was replaced")
+	}
+	if _, _, ok := archive.Object(PageKey(oldName + ".html")); ok {
+		t.Error("wrote into the archive while building a separate site")
+	}
+
+	manifest, err := LoadManifest(ctx, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, key := range ManifestKeys(manifest) {
+		found = found || key == PageKey(oldName+".html")
+	}
+	if !found {
+		t.Error("the old card page isn't in the manifest")
+	}
+	games, _, _ := out.Object(GamesIndexKey)
+	if !bytes.Contains(games, []byte(`href="page/`+oldName+`.html">HTML`)) {
+		t.Error("games.html doesn't link the old card page")
+	}
+
+	calendar, _, ok := out.Object(CalendarKey)
+	if !ok || !strings.Contains(string(calendar), "UID:776544@mlblg") {
+		t.Errorf("calendar.ics missing or without the schedule: %q", calendar)
+	}
+	for _, team := range config.WatchTeams {
+		if _, _, ok := out.Object(TeamCalendarKey(team)); !ok {
+			t.Errorf("no calendar for %s", team)
+		}
+	}
+	for _, key := range []string{"index.html", "baseball.gif", NotFoundKey, FeedKey} {
+		if _, _, ok := out.Object(key); !ok {
+			t.Errorf("%s wasn't written", key)
+		}
+	}
+}
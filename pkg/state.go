@@ -0,0 +1,165 @@
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	StateFilename       = "state.json"
+	WatcherStateVersion = 1
+	// Games older than this are dropped when the state is loaded
+	stateRetention = 7 * 24 * time.Hour
+)
+
+// StateStore keeps the WatcherState in memory behind a mutex and writes it
+// back to a JSON file after each run.
+type StateStore struct {
+	mutex sync.Mutex
+	path  string
+	state WatcherState
+}
+
+func StateFile() string {
+	return filepath.Join(ConfigDir(), StateFilename)
+}
+
+// LoadStateStore reads the state file, starting fresh when it's missing or
+// unreadable so a bad file never keeps the watcher from running.
+func LoadStateStore(path string) *StateStore {
+	store := &StateStore{
+		path:  path,
+		state: WatcherState{Version: WatcherStateVersion, Games: make(map[int]GameState)},
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &store.state)
+	}
+	if err != nil || store.state.Version != WatcherStateVersion {
+		log.Println("Ignoring unreadable state file", path, "error:", err)
+		store.state = WatcherState{Version: WatcherStateVersion, Games: make(map[int]GameState)}
+		return store
+	}
+	if store.state.Games == nil {
+		store.state.Games = make(map[int]GameState)
+	}
+	cutoff := time.Now().Add(-stateRetention)
+	for gamePk, game := range store.state.Games {
+		if game.LastChecked.Before(cutoff) {
+			delete(store.state.Games, gamePk)
+		}
+	}
+	log.Println("Loaded state for", len(store.state.Games), "games from", path)
+	return store
+}
+
+func (store *StateStore) Save() error {
+	store.mutex.Lock()
+	data, err := json.MarshalIndent(store.state, "", "    ")
+	store.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(store.path, data, 0640)
+}
+
+// Fingerprints the rendered report so a changed lineup can be told apart
+// from the one already published.
+func ReportFingerprint(report ReportData) string {
+	sum := sha256.Sum256([]byte(report.PageData + "\x00" + report.ReceiptData))
+	return hex.EncodeToString(sum[:])
+}
+
+// RecordCheck notes a lookup of a game, returning true the first time the
+// game is ever seen.
+func (store *StateStore) RecordCheck(gamePk int, filename string, matchup string, lookupErr string) bool {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	now := time.Now()
+	game, seen := store.state.Games[gamePk]
+	if !seen {
+		game = GameState{GamePk: gamePk, FirstSeen: now}
+	}
+	if filename != "" {
+		game.Filename = filename
+	}
+	if matchup != "" {
+		game.Matchup = matchup
+	}
+	game.LastChecked = now
+	game.Attempts++
+	// A good lookup doesn't clear a failed publish, only a publish does
+	if lookupErr != "" {
+		game.LastError = lookupErr
+	}
+	store.state.Games[gamePk] = game
+	return !seen
+}
+
+func (store *StateStore) RecordPublished(report ReportData, publishErr error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	game := store.state.Games[report.GamePk]
+	game.GamePk = report.GamePk
+	game.Filename = report.Filename
+	game.LastChecked = time.Now()
+	if publishErr != nil {
+		game.LastError = publishErr.Error()
+		store.state.Games[report.GamePk] = game
+		return
+	}
+	game.LastError = ""
+	if game.Published.IsZero() {
+		game.Published = time.Now()
+	}
+	game.Fingerprint = ReportFingerprint(report)
+	store.state.Games[report.GamePk] = game
+}
+
+// Published reports whether the game's card went out cleanly, so polling
+// can leave it alone. Games whose last attempt failed come back false and
+// get retried.
+func (store *StateStore) Published(gamePk int) bool {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	game := store.state.Games[gamePk]
+	return !game.Published.IsZero() && game.LastError == ""
+}
+
+// LineupChanged compares a report with the lineup last seen for a
+// published game and remembers the new one, so a change after the card
+// went out is only reported once.
+func (store *StateStore) LineupChanged(report ReportData) bool {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	game, ok := store.state.Games[report.GamePk]
+	fingerprint := ReportFingerprint(report)
+	if !ok || game.Fingerprint == "" || game.Fingerprint == fingerprint {
+		return false
+	}
+	game.Fingerprint = fingerprint
+	store.state.Games[report.GamePk] = game
+	return true
+}
+
+func (store *StateStore) Plan() SchedulePlan {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return store.state.Plan
+}
+
+func (store *StateStore) SetPlan(plan SchedulePlan) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.state.Plan = plan
+}
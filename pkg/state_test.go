@@ -0,0 +1,99 @@
+package pkg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStateStorePublished(t *testing.T) {
+	store := LoadStateStore(filepath.Join(t.TempDir(), StateFilename))
+	report := testReport(776543, "Minnesota Twins", "Detroit Tigers")
+
+	if !store.RecordCheck(report.GamePk, report.Filename, "Twins @ Tigers", "") {
+		t.Error("first check of a game wasn't reported as new")
+	}
+	if store.RecordCheck(report.GamePk, report.Filename, "Twins @ Tigers", "") {
+		t.Error("second check of a game was reported as new")
+	}
+	if store.Published(report.GamePk) {
+		t.Error("published before anything was written")
+	}
+
+	store.RecordPublished(report, errors.New("put refused"))
+	if store.Published(report.GamePk) {
+		t.Error("a failed publish counts as published")
+	}
+	// Looking the game up again doesn't hide the failed publish
+	store.RecordCheck(report.GamePk, report.Filename, "Twins @ Tigers", "")
+	if store.Published(report.GamePk) {
+		t.Error("a good lookup cleared the publish error")
+	}
+
+	store.RecordPublished(report, nil)
+	if !store.Published(report.GamePk) {
+		t.Error("not published after a clean publish")
+	}
+	if store.LineupChanged(report) {
+		t.Error("the published lineup counts as changed")
+	}
+	changed := report
+	changed.PageData += "PH 12 Jose Miranda\n"
+	if !store.LineupChanged(changed) {
+		t.Error("a different lineup didn't count as changed")
+	}
+	if store.LineupChanged(changed) {
+		t.Error("the same change was reported twice")
+	}
+}
+
+func TestStateStoreSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), StateFilename)
+	store := LoadStateStore(path)
+	report := testReport(776543, "Minnesota Twins", "Detroit Tigers")
+	store.RecordCheck(report.GamePk, report.Filename, "Twins @ Tigers", "")
+	store.RecordPublished(report, nil)
+	store.SetPlan(SchedulePlan{Date: "2025-08-11", Checks: []ScheduledCheck{{GamePk: 776544, Matchup: "Mets @ Phillies"}}})
+	err := store.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := LoadStateStore(path)
+	if !loaded.Published(report.GamePk) {
+		t.Error("published game lost across a restart")
+	}
+	if plan := loaded.Plan(); plan.Date != "2025-08-11" || len(plan.Checks) != 1 {
+		t.Errorf("plan %+v after a restart", plan)
+	}
+	if loaded.RecordCheck(report.GamePk, report.Filename, "", "") {
+		t.Error("a game seen before the restart was reported as new")
+	}
+}
+
+func TestLoadStateStorePrunesAndIgnoresBadFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), StateFilename)
+	err := os.WriteFile(path, []byte("{not json"), 0640)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := LoadStateStore(path)
+	if !store.RecordCheck(776543, "", "", "") {
+		t.Error("a corrupt state file wasn't ignored")
+	}
+
+	store.state.Games[776500] = GameState{GamePk: 776500, LastChecked: time.Now().Add(-2 * stateRetention)}
+	err = store.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := LoadStateStore(path)
+	if _, ok := loaded.state.Games[776500]; ok {
+		t.Error("a game older than the retention was kept")
+	}
+	if _, ok := loaded.state.Games[776543]; !ok {
+		t.Error("a recent game was dropped")
+	}
+}
@@ -0,0 +1,176 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	s3MaxAttempts = 5
+	s3MaxBackoff  = 2 * time.Second
+)
+
+type S3Storage struct {
+	Client *s3.Client
+	Bucket string
+}
+
+// S3Config points the storage at a bucket, optionally on an S3 compatible
+// service like MinIO. Empty fields fall back to the AWS SDK defaults.
+type S3Config struct {
+	Bucket       string
+	EndpointURL  string
+	Region       string
+	UsePathStyle bool
+}
+
+// Reads S3Config from REPORT_BUCKET, S3_ENDPOINT_URL, S3_REGION and
+// S3_PATH_STYLE, using defaultBucket when REPORT_BUCKET is unset.
+func S3ConfigFromEnv(defaultBucket string) S3Config {
+	s3Config := S3Config{
+		Bucket:      os.Getenv("REPORT_BUCKET"),
+		EndpointURL: os.Getenv("S3_ENDPOINT_URL"),
+		Region:      os.Getenv("S3_REGION"),
+	}
+	if s3Config.Bucket == "" {
+		s3Config.Bucket = defaultBucket
+	}
+	pathStyle, err := strconv.ParseBool(os.Getenv("S3_PATH_STYLE"))
+	if err == nil {
+		s3Config.UsePathStyle = pathStyle
+	}
+	return s3Config
+}
+
+func NewS3Storage(ctx context.Context, s3Config S3Config) (*S3Storage, error) {
+	// Throttling, 5xx and connection errors are retried with backoff by the
+	// SDK. The backoff is capped low so retries fit in the Lambda timeout.
+	loadOptions := []func(*config.LoadOptions) error{
+		config.WithRetryer(func() aws.Retryer {
+			return retry.NewStandard(func(options *retry.StandardOptions) {
+				options.MaxAttempts = s3MaxAttempts
+				options.MaxBackoff = s3MaxBackoff
+			})
+		}),
+	}
+	if s3Config.Region != "" {
+		loadOptions = append(loadOptions, config.WithRegion(s3Config.Region))
+	}
+	sdkConfig, err := config.LoadDefaultConfig(ctx, loadOptions...)
+	if err != nil {
+		return nil, err
+	}
+	client := s3.NewFromConfig(sdkConfig, func(options *s3.Options) {
+		if s3Config.EndpointURL != "" {
+			options.BaseEndpoint = aws.String(s3Config.EndpointURL)
+		}
+		options.UsePathStyle = s3Config.UsePathStyle
+	})
+	return &S3Storage{
+		Client: client,
+		Bucket: s3Config.Bucket,
+	}, nil
+}
+
+// isS3NotFound covers HeadObject's bodiless 404 as well as the NoSuchKey
+// error returned by GetObject.
+func isS3NotFound(err error) bool {
+	var notFound *types.NotFound
+	var noSuchKey *types.NoSuchKey
+	var responseErr *awshttp.ResponseError
+	if errors.As(err, &notFound) || errors.As(err, &noSuchKey) {
+		return true
+	}
+	return errors.As(err, &responseErr) && responseErr.HTTPStatusCode() == http.StatusNotFound
+}
+
+func (storage *S3Storage) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := storage.Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(storage.Bucket),
+		Key:    aws.String(key),
+	})
+	if isS3NotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, &StorageError{Op: "exists", Key: key, Err: err}
+	}
+	return true, nil
+}
+
+func (storage *S3Storage) Get(ctx context.Context, key string) ([]byte, error) {
+	object, err := storage.Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(storage.Bucket),
+		Key:    aws.String(key),
+	})
+	if isS3NotFound(err) {
+		return nil, &StorageError{Op: "get", Key: key, Err: ErrNotFound}
+	}
+	if err != nil {
+		return nil, &StorageError{Op: "get", Key: key, Err: err}
+	}
+	defer object.Body.Close()
+	data, err := io.ReadAll(object.Body)
+	if err != nil {
+		return nil, &StorageError{Op: "get", Key: key, Err: err}
+	}
+	return data, nil
+}
+
+func (storage *S3Storage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	putObjInput := &s3.PutObjectInput{
+		Bucket: aws.String(storage.Bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	}
+	if contentType != "" {
+		putObjInput.ContentType = aws.String(contentType)
+	}
+	_, err := storage.Client.PutObject(ctx, putObjInput)
+	if err != nil {
+		return &StorageError{Op: "put", Key: key, Err: err}
+	}
+	return nil
+}
+
+func (storage *S3Storage) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	paginator := s3.NewListObjectsV2Paginator(storage.Client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(storage.Bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return keys, &StorageError{Op: "list", Key: prefix, Err: err}
+		}
+		for _, object := range page.Contents {
+			keys = append(keys, aws.ToString(object.Key))
+		}
+	}
+	return keys, nil
+}
+
+func (storage *S3Storage) Delete(ctx context.Context, key string) error {
+	_, err := storage.Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(storage.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return &StorageError{Op: "delete", Key: key, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const testBucket = "scorecards-test"
+
+// s3StandIn speaks just enough of the S3 REST API for HeadObject, GetObject
+// and PutObject on one bucket, addressed path style. failures forces a
+// status for "METHOD key".
+type s3StandIn struct {
+	t        *testing.T
+	mutex    sync.Mutex
+	objects  map[string][]byte
+	types    map[string]string
+	failures map[string]int
+}
+
+func newS3StandIn(t *testing.T) (*s3StandIn, *httptest.Server) {
+	standIn := &s3StandIn{
+		t:        t,
+		objects:  make(map[string][]byte),
+		types:    make(map[string]string),
+		failures: make(map[string]int),
+	}
+	server := httptest.NewServer(standIn)
+	t.Cleanup(server.Close)
+	return standIn, server
+}
+
+func (standIn *s3StandIn) fail(method string, key string, status int) {
+	standIn.mutex.Lock()
+	defer standIn.mutex.Unlock()
+	standIn.failures[method+" "+key] = status
+}
+
+func (standIn *s3StandIn) object(key string) ([]byte, string, bool) {
+	standIn.mutex.Lock()
+	defer standIn.mutex.Unlock()
+	data, ok := standIn.objects[key]
+	return data, standIn.types[key], ok
+}
+
+func writeS3Error(writer http.ResponseWriter, request *http.Request, status int, code string) {
+	writer.Header().Set("Content-Type", "application/xml")
+	writer.WriteHeader(status)
+	if request.Method != http.MethodHead {
+		fmt.Fprintf(writer, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+	}
+}
+
+func (standIn *s3StandIn) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	key, pathStyle := strings.CutPrefix(request.URL.Path, "/"+testBucket+"/")
+	if !pathStyle {
+		standIn.t.Errorf("%s %s isn't a path style request for %s", request.Method, request.URL, testBucket)
+		writeS3Error(writer, request, http.StatusBadRequest, "InvalidRequest")
+		return
+	}
+	standIn.mutex.Lock()
+	defer standIn.mutex.Unlock()
+	if status, ok := standIn.failures[request.Method+" "+key]; ok {
+		writeS3Error(writer, request, status, http.StatusText(status))
+		return
+	}
+	switch request.Method {
+	case http.MethodHead, http.MethodGet:
+		data, ok := standIn.objects[key]
+		if !ok {
+			writeS3Error(writer, request, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		writer.Header().Set("Content-Type", standIn.types[key])
+		writer.Header().Set("Content-Length", fmt.Sprint(len(data)))
+		if request.Method == http.MethodGet {
+			writer.Write(data)
+		}
+	case http.MethodPut:
+		data, err := io.ReadAll(request.Body)
+		if err != nil {
+			writeS3Error(writer, request, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		standIn.objects[key] = data
+		standIn.types[key] = request.Header.Get("Content-Type")
+		writer.Header().Set("ETag", `"stand-in"`)
+	default:
+		writeS3Error(writer, request, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+// Points NewS3Storage at the stand-in the way the Lambda is configured.
+func newTestS3Storage(t *testing.T, endpoint string) *S3Storage {
+	t.Setenv("REPORT_BUCKET", testBucket)
+	t.Setenv("S3_ENDPOINT_URL", endpoint)
+	t.Setenv("S3_PATH_STYLE", "true")
+	t.Setenv("S3_REGION", "us-east-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	t.Setenv("AWS_RESPONSE_CHECKSUM_VALIDATION", "when_required")
+	storage, err := NewS3Storage(context.Background(), S3ConfigFromEnv("scorecards.jjhsk.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if storage.Bucket != testBucket {
+		t.Fatalf("bucket %q, want %q", storage.Bucket, testBucket)
+	}
+	return storage
+}
+
+// The same client without the SDK retries, so failures come back at once.
+func withoutRetries(storage *S3Storage) *S3Storage {
+	client := s3.New(storage.Client.Options(), func(options *s3.Options) {
+		options.Retryer = aws.NopRetryer{}
+	})
+	return &S3Storage{Client: client, Bucket: storage.Bucket}
+}
+
+func TestS3StoragePublishReport(t *testing.T) {
+	ctx := context.Background()
+	standIn, server := newS3StandIn(t)
+	storage := newTestS3Storage(t, server.URL)
+	report := testReport(776543, "Minnesota Twins", "Detroit Tigers")
+
+	published, err := PublishReport(ctx, storage, report, ConfigData{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ReportKeys(report, ConfigData{})
+	if !reflect.DeepEqual(published, want) {
+		t.Errorf("published %v, want %v", published, want)
+	}
+	for _, key := range want {
+		data, contentType, ok := standIn.object(key)
+		if !ok || len(data) == 0 {
+			t.Errorf("%s wasn't stored", key)
+			continue
+		}
+		stored, err := storage.Get(ctx, key)
+		if err != nil || string(stored) != string(data) {
+			t.Errorf("got %s back as %d bytes, %v", key, len(stored), err)
+		}
+		if key == PageKey(report.Filename) && (contentType != "application/pdf" || !strings.HasPrefix(string(data), "%PDF-")) {
+			t.Errorf("%s stored as %q, not the raw pdf", key, contentType)
+		}
+	}
+
+	published, err = PublishReport(ctx, storage, report, ConfigData{})
+	if err != nil || len(published) != 0 {
+		t.Errorf("published %v again, %v", published, err)
+	}
+}
+
+func TestS3StorageErrors(t *testing.T) {
+	ctx := context.Background()
+	standIn, server := newS3StandIn(t)
+	storage := withoutRetries(newTestS3Storage(t, server.URL))
+
+	exists, err := storage.Exists(ctx, "page/missing.pdf")
+	if exists || err != nil {
+		t.Errorf("missing key exists=%v, %v", exists, err)
+	}
+	for _, status := range []int{http.StatusForbidden, http.StatusServiceUnavailable} {
+		key := fmt.Sprintf("page/%d.pdf", status)
+		standIn.fail(http.MethodHead, key, status)
+		exists, err := storage.Exists(ctx, key)
+		var storageErr *StorageError
+		if exists || !errors.As(err, &storageErr) || storageErr.Op != "exists" {
+			t.Errorf("HEAD %d: exists=%v, %v", status, exists, err)
+		}
+		if errors.Is(err, ErrNotFound) {
+			t.Errorf("HEAD %d counted as a missing key", status)
+		}
+	}
+
+	_, err = storage.Get(ctx, "page/missing.pdf")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("GET of a missing key: %v", err)
+	}
+	standIn.fail(http.MethodGet, "manifest.json", http.StatusForbidden)
+	_, err = storage.Get(ctx, "manifest.json")
+	if err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("GET 403: %v", err)
+	}
+
+	report := testReport(776543, "Minnesota Twins", "Detroit Tigers")
+	standIn.fail(http.MethodPut, PageKey(report.Filename), http.StatusInternalServerError)
+	published, err := PublishReport(ctx, storage, report, ConfigData{})
+	var storageErr *StorageError
+	if !errors.As(err, &storageErr) || storageErr.Op != "put" || storageErr.Key != PageKey(report.Filename) {
+		t.Fatalf("failed put came back as %v", err)
+	}
+	if !reflect.DeepEqual(published, []string{ReceiptKey(report.Filename)}) {
+		t.Errorf("published %v before the failure", published)
+	}
+}
@@ -0,0 +1,228 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Storage is where published reports and index pages end up. Keys always
+// use forward slashes, e.g. "page/2025-08-11-Minnesota-Twins-at-...pdf".
+type Storage interface {
+	Exists(ctx context.Context, key string) (bool, error)
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	List(ctx context.Context, prefix string) ([]string, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// ErrNotFound is wrapped by the StorageError Get returns for a missing key.
+var ErrNotFound = errors.New("not found")
+
+// StorageError records which operation on which key failed so publish
+// failures can be told apart from missing objects.
+type StorageError struct {
+	Op  string
+	Key string
+	Err error
+}
+
+func (storageErr *StorageError) Error() string {
+	return fmt.Sprintf("storage %s %s: %v", storageErr.Op, storageErr.Key, storageErr.Err)
+}
+
+func (storageErr *StorageError) Unwrap() error {
+	return storageErr.Err
+}
+
+// FileStorage keeps objects as plain files under Root. Content types are
+// not stored since the file extension already carries them.
+type FileStorage struct {
+	Root string
+}
+
+func NewFileStorage(root string) *FileStorage {
+	return &FileStorage{Root: root}
+}
+
+func (storage *FileStorage) filename(key string) string {
+	return filepath.Join(storage.Root, filepath.FromSlash(key))
+}
+
+func (storage *FileStorage) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := os.Stat(storage.filename(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, &StorageError{Op: "exists", Key: key, Err: err}
+	}
+	return true, nil
+}
+
+func (storage *FileStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(storage.filename(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, &StorageError{Op: "get", Key: key, Err: ErrNotFound}
+	}
+	if err != nil {
+		return nil, &StorageError{Op: "get", Key: key, Err: err}
+	}
+	return data, nil
+}
+
+func (storage *FileStorage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	filename := storage.filename(key)
+	err := os.MkdirAll(filepath.Dir(filename), 0750)
+	if err == nil {
+		err = writeFileAtomic(filename, data, 0640)
+	}
+	if err != nil {
+		return &StorageError{Op: "put", Key: key, Err: err}
+	}
+	return nil
+}
+
+// Writes to a temporary file next to filename and renames it into place so
+// readers never see a half written file.
+func writeFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	tempFile, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tempName := tempFile.Name()
+	_, err = tempFile.Write(data)
+	if err == nil {
+		err = tempFile.Chmod(perm)
+	}
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tempName, filename)
+	}
+	if err != nil {
+		os.Remove(tempName)
+	}
+	return err
+}
+
+func (storage *FileStorage) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	searchDir := ""
+	if ind := strings.LastIndex(prefix, "/"); ind >= 0 {
+		searchDir = prefix[:ind]
+	}
+	root := storage.filename(searchDir)
+	err := filepath.WalkDir(root, func(filename string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		relative, err := filepath.Rel(root, filename)
+		if err != nil {
+			return err
+		}
+		key := path.Join(searchDir, filepath.ToSlash(relative))
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return keys, nil
+	}
+	if err != nil {
+		return keys, &StorageError{Op: "list", Key: prefix, Err: err}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (storage *FileStorage) Delete(ctx context.Context, key string) error {
+	err := os.Remove(storage.filename(key))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return &StorageError{Op: "delete", Key: key, Err: err}
+	}
+	return nil
+}
+
+type memoryObject struct {
+	Data        []byte
+	ContentType string
+}
+
+// MemoryStorage is an in-process Storage meant for tests and dry runs.
+type MemoryStorage struct {
+	mutex   sync.Mutex
+	objects map[string]memoryObject
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{objects: make(map[string]memoryObject)}
+}
+
+func (storage *MemoryStorage) Exists(ctx context.Context, key string) (bool, error) {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+	_, ok := storage.objects[key]
+	return ok, nil
+}
+
+func (storage *MemoryStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+	object, ok := storage.objects[key]
+	if !ok {
+		return nil, &StorageError{Op: "get", Key: key, Err: ErrNotFound}
+	}
+	return append([]byte(nil), object.Data...), nil
+}
+
+func (storage *MemoryStorage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+	storage.objects[key] = memoryObject{
+		Data:        append([]byte(nil), data...),
+		ContentType: contentType,
+	}
+	return nil
+}
+
+func (storage *MemoryStorage) List(ctx context.Context, prefix string) ([]string, error) {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+	var keys []string
+	for key := range storage.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (storage *MemoryStorage) Delete(ctx context.Context, key string) error {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+	delete(storage.objects, key)
+	return nil
+}
+
+// Returns the stored bytes and content type of a key, mostly useful for
+// checking what a publish run produced.
+func (storage *MemoryStorage) Object(key string) ([]byte, string, bool) {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+	object, ok := storage.objects[key]
+	return object.Data, object.ContentType, ok
+}
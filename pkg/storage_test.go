@@ -0,0 +1,303 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// A live report with just enough filled in to render every format.
+func testReport(gamePk int, away string, home string) ReportData {
+	report := ReportData{
+		Filename:    ReportFilename("2025-08-11", away, home, gamePk),
+		GamePk:      gamePk,
+		Live:        true,
+		OK:          true,
+		Message:     away + " @ " + home + "\n7:05 PM",
+		ReceiptData: away + " @ " + home + "\n7:05 PM\n\n----- " + away + " -----\n",
+		PageData:    away + " @ " + home + "\n7:05 PM\n",
+		AwayTeam:    StartingList{TeamName: away, OK: true},
+		HomeTeam:    StartingList{TeamName: home, OK: true},
+	}
+	report.GameData.Teams.Away.Name = away
+	report.GameData.Teams.Home.Name = home
+	report.GameData.Datetime.OfficialDate = "2025-08-11"
+	return report
+}
+
+// Fails every Put of one key, leaving the rest to the wrapped storage.
+type failingStorage struct {
+	Storage
+	failKey string
+}
+
+var errTestPut = errors.New("put refused")
+
+func (storage failingStorage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	if key == storage.failKey {
+		return &StorageError{Op: "put", Key: key, Err: errTestPut}
+	}
+	return storage.Storage.Put(ctx, key, data, contentType)
+}
+
+func TestPublishReport(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	report := testReport(776543, "Minnesota Twins", "Detroit Tigers")
+
+	published, err := PublishReport(ctx, storage, report, ConfigData{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		ReceiptKey(report.Filename),
+		PageKey(report.Filename),
+		PageKey(HTMLFilename(report.Filename)),
+		PageKey(JSONFilename(report.Filename)),
+	}
+	if !reflect.DeepEqual(published, want) {
+		t.Errorf("published %v, want %v", published, want)
+	}
+	for _, key := range want {
+		data, contentType, ok := storage.Object(key)
+		if !ok || len(data) == 0 {
+			t.Errorf("%s wasn't stored", key)
+		}
+		if contentType != reportContentTypes[strings.TrimPrefix(filepath.Ext(key), ".")] {
+			t.Errorf("%s stored as %s", key, contentType)
+		}
+	}
+
+	published, err = PublishReport(ctx, storage, report, ConfigData{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(published) != 0 {
+		t.Errorf("published %v again", published)
+	}
+
+	published, err = PublishReport(ctx, storage, report, ConfigData{Formats: []string{"markdown"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(published, []string{PageKey(MarkdownFilename(report.Filename))}) {
+		t.Errorf("published %v, want only the markdown card", published)
+	}
+}
+
+func TestPublishReportPartialFailure(t *testing.T) {
+	ctx := context.Background()
+	report := testReport(776543, "Minnesota Twins", "Detroit Tigers")
+	memory := NewMemoryStorage()
+	storage := failingStorage{Storage: memory, failKey: PageKey(HTMLFilename(report.Filename))}
+
+	published, err := PublishReport(ctx, storage, report, ConfigData{})
+	if !errors.Is(err, errTestPut) {
+		t.Fatalf("error = %v, want the failed put", err)
+	}
+	want := []string{ReceiptKey(report.Filename), PageKey(report.Filename)}
+	if !reflect.DeepEqual(published, want) {
+		t.Errorf("published %v, want %v", published, want)
+	}
+	if exists, _ := memory.Exists(ctx, PageKey(JSONFilename(report.Filename))); exists {
+		t.Error("kept publishing after the failed put")
+	}
+}
+
+func TestFileStorageList(t *testing.T) {
+	ctx := context.Background()
+	storage := NewFileStorage(t.TempDir())
+	for _, key := range []string{"page/b.pdf", "page/a.pdf", "page/old/c.pdf", "receipt/a.pdf", "pages.html"} {
+		err := storage.Put(ctx, key, []byte(key), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"page/", []string{"page/a.pdf", "page/b.pdf", "page/old/c.pdf"}},
+		{"page/a", []string{"page/a.pdf"}},
+		{"page", []string{"page/a.pdf", "page/b.pdf", "page/old/c.pdf", "pages.html"}},
+		{"receipt/", []string{"receipt/a.pdf"}},
+		{"missing/", nil},
+	}
+	for _, test := range tests {
+		keys, err := storage.List(ctx, test.prefix)
+		if err != nil {
+			t.Errorf("List(%q): %v", test.prefix, err)
+			continue
+		}
+		if !reflect.DeepEqual(keys, test.want) {
+			t.Errorf("List(%q) = %v, want %v", test.prefix, keys, test.want)
+		}
+	}
+}
+
+func TestFileStorageGetMissing(t *testing.T) {
+	storage := NewFileStorage(t.TempDir())
+	_, err := storage.Get(context.Background(), "page/missing.pdf")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestPublishIndexPages(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	config := ConfigData{SiteURL: "https://cards.example.com"}
+	var keys []string
+	for _, report := range []ReportData{
+		testReport(776543, "Minnesota Twins", "Detroit Tigers"),
+		testReport(776544, "New York Mets", "Philadelphia Phillies"),
+	} {
+		published, err := PublishReport(ctx, storage, report, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, published...)
+	}
+
+	err := PublishIndexPages(ctx, storage, keys, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifestData, _, ok := storage.Object(ManifestKey)
+	if !ok {
+		t.Fatal("no manifest written")
+	}
+	var manifest Manifest
+	err = json.Unmarshal(manifestData, &manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Reports) != len(keys) {
+		t.Errorf("manifest has %d reports, want %d", len(manifest.Reports), len(keys))
+	}
+
+	index, contentType, _ := storage.Object(GamesIndexKey)
+	if contentType != "text/html" || !strings.Contains(string(index), "page/2025-08-11-Minnesota-Twins-at-Detroit-Tigers-776543.pdf") {
+		t.Errorf("games index is missing the Twins card:\n%s", index)
+	}
+	teamIndex, _, ok := storage.Object(TeamIndexKey("New York Mets"))
+	if !ok {
+		t.Fatal("no Mets team page")
+	}
+	if strings.Contains(string(teamIndex), "Minnesota-Twins") {
+		t.Error("Mets team page lists the Twins game")
+	}
+
+	feedData, contentType, _ := storage.Object(FeedKey)
+	if contentType != "application/atom+xml" {
+		t.Errorf("feed stored as %s", contentType)
+	}
+	var feed AtomFeed
+	err = xml.Unmarshal(feedData, &feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(feed.Entries) != 2 {
+		t.Errorf("feed has %d entries, want 2", len(feed.Entries))
+	}
+	for _, entry := range feed.Entries {
+		for _, link := range entry.Links {
+			if !strings.HasPrefix(link.Href, config.SiteURL+"/") {
+				t.Errorf("feed link %s isn't absolute", link.Href)
+			}
+		}
+	}
+	if _, _, ok := storage.Object(TeamFeedKey("Detroit Tigers")); !ok {
+		t.Error("no Tigers team feed")
+	}
+}
+
+func TestPublishIndexPagesNothingNew(t *testing.T) {
+	storage := NewMemoryStorage()
+	err := PublishIndexPages(context.Background(), storage, nil, ConfigData{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys, _ := storage.List(context.Background(), ""); len(keys) != 0 {
+		t.Errorf("wrote %v with nothing published", keys)
+	}
+}
+
+func TestLoadManifestBootstrap(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	report := testReport(776543, "Minnesota Twins", "Detroit Tigers")
+	published, err := PublishReport(ctx, storage, report, ConfigData{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := LoadManifest(ctx, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := ManifestKeys(manifest)
+	if len(keys) != len(published) {
+		t.Errorf("bootstrapped %v, want %v", keys, published)
+	}
+	games := CollectPublishedGames(keys)
+	if len(games) != 1 || games[0].GamePk != 776543 || games[0].Away != "Minnesota Twins" {
+		t.Errorf("collected %+v", games)
+	}
+}
+
+func TestPublishIndexPagesReconciles(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	config := ConfigData{}
+	first := testReport(776543, "Minnesota Twins", "Detroit Tigers")
+	_, err := PublishReport(ctx, storage, first, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = PublishIndexPages(ctx, storage, ReportKeys(first, config), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The run for the second card died after its artifacts were stored, so
+	// the retry publishes nothing new but still has to index it
+	second := testReport(776544, "New York Mets", "Philadelphia Phillies")
+	_, err = PublishReport(ctx, storage, second, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	published, err := PublishReport(ctx, storage, second, config)
+	if err != nil || len(published) != 0 {
+		t.Fatalf("retry published %v, %v", published, err)
+	}
+	err = PublishIndexPages(ctx, storage, ReportKeys(second, config), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := LoadManifest(ctx, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Reports) != 8 {
+		t.Errorf("manifest has %v, want both cards", ManifestKeys(manifest))
+	}
+	index, _, _ := storage.Object(GamesIndexKey)
+	if !strings.Contains(string(index), "New-York-Mets") {
+		t.Error("games index is missing the reconciled Mets card")
+	}
+
+	// Keys the manifest already has don't touch anything
+	storage.Delete(ctx, GamesIndexKey)
+	err = PublishIndexPages(ctx, storage, ReportKeys(second, config), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := storage.Object(GamesIndexKey); ok {
+		t.Error("rebuilt the indexes with nothing missing from the manifest")
+	}
+}
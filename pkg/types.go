@@ -1,12 +1,90 @@
 package pkg
 
-import ()
+import (
+	"encoding/xml"
+	"time"
+)
 
 type ConfigData struct {
 	WatchTeams  []string
 	ReportPath  string
 	ReceiptPath string
 	PagePath    string
+	// host:port of a raw ESC/POS receipt printer, empty to disable
+	ReceiptPrinter string
+	// Extra report formats written next to the pdfs: "markdown", "text"
+	Formats []string
+	// Skip the team color bands and logos on page pdfs
+	PlainPages bool
+	// Directory holding <teamId>.png/.jpg logos, also used as the download cache
+	TeamLogoDir string
+	// Optional logo URL with {id} replaced by the team id, fetched into TeamLogoDir
+	TeamLogoURL string
+	// Print a QR code linking to the game's Gameday page on every card
+	GamedayQR bool
+	// Optional Gameday URL with {pk} replaced by the game pk
+	GamedayURL string
+	// Optional TTF files replacing the embedded Liberation Mono fonts
+	RegularFontFile string
+	BoldFontFile    string
+	// Public URL of the published site, used for absolute links in the Atom feeds
+	SiteURL string
+	// Receivers notified whenever a new card is published
+	Webhooks []WebhookConfig
+	// Optional file every webhook delivery attempt is appended to as a JSON line
+	WebhookLogFile string
+	// Slack and Discord incoming webhooks that get a formatted lineup post
+	ChatWebhooks []ChatWebhookConfig
+	// Mail server the cards are emailed through, empty Host to disable
+	SMTP SMTPConfig
+	// Team name to the addresses that get that team's cards by email
+	EmailSubscribers map[string][]string
+	// CUPS/IPP printer new page cards are sent to
+	PagePrinter PagePrinterConfig
+}
+
+type PagePrinterConfig struct {
+	// IPP printer URI like ipp://cups.local:631/printers/Office, used over lp when set
+	IPPURL string
+	// CUPS queue name handed to lp -d
+	Printer string
+	// Defaults to 1
+	Copies int
+	// Input tray, e.g. "tray-2", empty for the printer default
+	Tray string
+	// lp binary to run, defaults to lp
+	LpCommand string
+}
+
+type SMTPConfig struct {
+	Host string
+	// Defaults to 587
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+type ChatWebhookConfig struct {
+	// "slack" or "discord"
+	Kind string
+	URL  string
+	// Only post games these teams play in, empty for every watched team
+	Teams []string
+}
+
+type WebhookConfig struct {
+	URL string
+	// Signs the payload with HMAC-SHA256 when set
+	Secret string
+}
+
+type TeamInfo struct {
+	Id             int
+	Name           string
+	Abbreviation   string
+	PrimaryColor   string
+	SecondaryColor string
 }
 
 type GameLink struct {
@@ -39,6 +117,7 @@ type Game struct {
 	OfficialDate string
 	Status       GameStatus
 	Teams        GameTeams
+	Venue        VenueData
 }
 
 type WeatherData struct {
@@ -62,6 +141,7 @@ type GameContent struct {
 
 type GameStatus struct {
 	AbstractGameState string
+	DetailedState     string
 	StatusCode        string
 }
 
@@ -159,6 +239,7 @@ type PeopleInfo struct {
 }
 
 type PlayerData struct {
+	Id            int
 	FullName      string
 	PrimaryNumber string
 	PitchHand     PlayerPitchInfo
@@ -199,6 +280,7 @@ type BullpenList struct {
 }
 
 type BullpenInfo struct {
+	Id     int
 	Name   string
 	Number string
 	Handed string
@@ -211,11 +293,13 @@ type BenchList struct {
 }
 
 type BenchInfo struct {
+	Id     int
 	Name   string
 	Number string
 }
 
 type BatOrderInfo struct {
+	Id           int
 	Position     string
 	Name         string
 	JerseyNumber string
@@ -269,13 +353,24 @@ type DivisionStandings struct {
 	standings [5]StandingsTeam
 }
 
+type NamedDivisionStandings struct {
+	Name  string
+	Teams []StandingsTeam
+}
+
 type ReportData struct {
 	ReceiptData string
 	PageData    string
 	Message     string
 	Filename    string
+	GamePk      int
 	Live        bool
 	OK          bool
+	GameData    LiveGameData
+	AwayTeam    StartingList
+	HomeTeam    StartingList
+	Officials   Officials
+	Standings   StandingsData
 }
 
 type Officials struct {
@@ -284,3 +379,267 @@ type Officials struct {
 	Second string
 	Third  string
 }
+
+type LineupReport struct {
+	Version     int              `json:"version"`
+	GamePk      int              `json:"gamePk"`
+	GeneratedAt string           `json:"generatedAt"`
+	Date        string           `json:"date"`
+	Time        string           `json:"time"`
+	Venue       LineupVenue      `json:"venue"`
+	Weather     LineupWeather    `json:"weather"`
+	Away        LineupTeam       `json:"away"`
+	Home        LineupTeam       `json:"home"`
+	Officials   LineupOfficials  `json:"officials"`
+	Standings   []LineupDivision `json:"standings"`
+}
+
+type LineupVenue struct {
+	Name  string `json:"name"`
+	City  string `json:"city"`
+	State string `json:"state"`
+}
+
+type LineupWeather struct {
+	Temp      string `json:"temp"`
+	Condition string `json:"condition"`
+}
+
+type LineupTeam struct {
+	Id              int             `json:"id"`
+	Name            string          `json:"name"`
+	Wins            int             `json:"wins"`
+	Losses          int             `json:"losses"`
+	BattingOrder    []LineupBatter  `json:"battingOrder"`
+	StartingPitcher LineupPitcher   `json:"startingPitcher"`
+	Bullpen         []LineupPitcher `json:"bullpen"`
+	Bench           []LineupPlayer  `json:"bench"`
+}
+
+type LineupBatter struct {
+	Order    int    `json:"order"`
+	Id       int    `json:"id"`
+	Name     string `json:"name"`
+	Number   string `json:"number"`
+	Position string `json:"position"`
+}
+
+type LineupPitcher struct {
+	Id     int    `json:"id"`
+	Name   string `json:"name"`
+	Number string `json:"number"`
+	Throws string `json:"throws"`
+}
+
+type LineupPlayer struct {
+	Id     int    `json:"id"`
+	Name   string `json:"name"`
+	Number string `json:"number"`
+}
+
+type LineupOfficials struct {
+	Home   string `json:"home"`
+	First  string `json:"first"`
+	Second string `json:"second"`
+	Third  string `json:"third"`
+}
+
+type LineupDivision struct {
+	Name  string                `json:"name"`
+	Teams []LineupStandingsTeam `json:"teams"`
+}
+
+type LineupStandingsTeam struct {
+	Abbreviation string `json:"abbreviation"`
+	GamesBack    string `json:"gamesBack"`
+}
+
+type PublishedGame struct {
+	Name   string
+	Date   string
+	GamePk int
+	Away   string
+	Home   string
+	Links  []PublishedLink
+}
+
+type PublishedLink struct {
+	Label string
+	Href  string
+}
+
+type Manifest struct {
+	Version int             `json:"version"`
+	Updated string          `json:"updated"`
+	Reports []ManifestEntry `json:"reports"`
+}
+
+type ManifestEntry struct {
+	Key       string `json:"key"`
+	Published string `json:"published"`
+}
+
+type AtomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Id      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  AtomAuthor  `xml:"author"`
+	Links   []AtomLink  `xml:"link"`
+	Entries []AtomEntry `xml:"entry"`
+}
+
+type AtomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type AtomLink struct {
+	Rel   string `xml:"rel,attr,omitempty"`
+	Type  string `xml:"type,attr,omitempty"`
+	Title string `xml:"title,attr,omitempty"`
+	Href  string `xml:"href,attr"`
+}
+
+type AtomEntry struct {
+	Id      string     `xml:"id"`
+	Title   string     `xml:"title"`
+	Updated string     `xml:"updated"`
+	Summary string     `xml:"summary"`
+	Links   []AtomLink `xml:"link"`
+}
+
+type APIGame struct {
+	GamePk    int    `json:"gamePk"`
+	Date      string `json:"date"`
+	StartTime string `json:"startTime"`
+	Away      string `json:"away"`
+	Home      string `json:"home"`
+	Venue     string `json:"venue"`
+	Status    string `json:"status"`
+	Lineup    string `json:"lineup"`
+}
+
+type APIReport struct {
+	GamePk int             `json:"gamePk"`
+	Date   string          `json:"date"`
+	Away   string          `json:"away"`
+	Home   string          `json:"home"`
+	Links  []APIReportLink `json:"links"`
+}
+
+type APIReportLink struct {
+	Format string `json:"format"`
+	Href   string `json:"href"`
+}
+
+type APIError struct {
+	Error string `json:"error"`
+}
+
+type WebhookPayload struct {
+	Version int           `json:"version"`
+	Event   string        `json:"event"`
+	SentAt  string        `json:"sentAt"`
+	Game    WebhookGame   `json:"game"`
+	Links   []WebhookLink `json:"links"`
+}
+
+type WebhookGame struct {
+	GamePk int    `json:"gamePk"`
+	Date   string `json:"date"`
+	Time   string `json:"time"`
+	Venue  string `json:"venue"`
+	Away   string `json:"away"`
+	Home   string `json:"home"`
+}
+
+type WebhookLink struct {
+	Format string `json:"format"`
+	Href   string `json:"href"`
+}
+
+type WebhookDelivery struct {
+	Id       string `json:"id"`
+	URL      string `json:"url"`
+	GamePk   int    `json:"gamePk"`
+	Attempt  int    `json:"attempt"`
+	Status   int    `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Sent     string `json:"sent"`
+	Duration string `json:"duration"`
+}
+
+type SlackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []SlackBlock `json:"blocks"`
+}
+
+type SlackBlock struct {
+	Type     string      `json:"type"`
+	Text     *SlackText  `json:"text,omitempty"`
+	Fields   []SlackText `json:"fields,omitempty"`
+	Elements []SlackText `json:"elements,omitempty"`
+}
+
+type SlackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type DiscordMessage struct {
+	Content string         `json:"content"`
+	Embeds  []DiscordEmbed `json:"embeds"`
+}
+
+type DiscordEmbed struct {
+	Title       string         `json:"title"`
+	URL         string         `json:"url,omitempty"`
+	Description string         `json:"description"`
+	Color       int            `json:"color"`
+	Fields      []DiscordField `json:"fields"`
+	Footer      *DiscordFooter `json:"footer,omitempty"`
+}
+
+type DiscordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type DiscordFooter struct {
+	Text string `json:"text"`
+}
+
+// When each of the day's games next gets looked at, see scheduler.go
+type SchedulePlan struct {
+	Date   string           `json:"date"`
+	Checks []ScheduledCheck `json:"checks"`
+}
+
+type ScheduledCheck struct {
+	GamePk    int           `json:"gamePk"`
+	Matchup   string        `json:"matchup"`
+	Start     time.Time     `json:"start"`
+	NextCheck time.Time     `json:"nextCheck"`
+	Interval  time.Duration `json:"interval"`
+	Done      bool          `json:"done"`
+}
+
+// What cmd/local remembers between restarts, see state.go
+type WatcherState struct {
+	Version int               `json:"version"`
+	Games   map[int]GameState `json:"games"`
+	Plan    SchedulePlan      `json:"plan"`
+}
+
+type GameState struct {
+	GamePk      int       `json:"gamePk"`
+	Filename    string    `json:"filename"`
+	Matchup     string    `json:"matchup"`
+	FirstSeen   time.Time `json:"firstSeen"`
+	LastChecked time.Time `json:"lastChecked"`
+	Attempts    int       `json:"attempts"`
+	LastError   string    `json:"lastError,omitempty"`
+	Published   time.Time `json:"published,omitempty"`
+	Fingerprint string    `json:"fingerprint,omitempty"`
+}
@@ -0,0 +1,227 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	WebhookPayloadVersion = 1
+	WebhookEventPublished = "card.published"
+	webhookMaxAttempts    = 4
+	webhookFirstBackoff   = time.Second
+	webhookTimeout        = 10 * time.Second
+	// Held back from the caller's deadline so a Lambda that gave up on slow
+	// receivers still returns cleanly
+	webhookDeadlineMargin = 2 * time.Second
+)
+
+// Receivers check X-MLBLG-Signature against an HMAC-SHA256 of the raw body
+// keyed with the shared secret.
+const (
+	webhookSignatureHeader = "X-MLBLG-Signature"
+	webhookEventHeader     = "X-MLBLG-Event"
+	webhookDeliveryHeader  = "X-MLBLG-Delivery"
+)
+
+var webhookClient = &http.Client{Timeout: webhookTimeout}
+
+// Reads webhooks from WEBHOOK_URLS, a comma separated list, all signed
+// with WEBHOOK_SECRET.
+func WebhooksFromEnv() []WebhookConfig {
+	var webhooks []WebhookConfig
+	secret := os.Getenv("WEBHOOK_SECRET")
+	for _, webhookURL := range strings.Split(os.Getenv("WEBHOOK_URLS"), ",") {
+		webhookURL = strings.TrimSpace(webhookURL)
+		if webhookURL != "" {
+			webhooks = append(webhooks, WebhookConfig{URL: webhookURL, Secret: secret})
+		}
+	}
+	return webhooks
+}
+
+func SignWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Labels a published key by its format, e.g. receipt.pdf or page.html.
+func webhookFormat(key string) string {
+	prefix, filename := path.Split(key)
+	return strings.TrimSuffix(prefix, "/") + path.Ext(filename)
+}
+
+func BuildWebhookPayload(report ReportData, published []string, config ConfigData) WebhookPayload {
+	game := report.GameData
+	payload := WebhookPayload{
+		Version: WebhookPayloadVersion,
+		Event:   WebhookEventPublished,
+		SentAt:  time.Now().UTC().Format(time.RFC3339),
+		Game: WebhookGame{
+			GamePk: report.GamePk,
+			Date:   game.Datetime.OfficialDate,
+			Time:   game.Datetime.Time + game.Datetime.Ampm,
+			Venue:  game.Venue.Name,
+			Away:   game.Teams.Away.Name,
+			Home:   game.Teams.Home.Name,
+		},
+		Links: []WebhookLink{},
+	}
+	for _, key := range published {
+		payload.Links = append(payload.Links, WebhookLink{
+			Format: webhookFormat(key),
+			Href:   feedHref(config.SiteURL, "", key),
+		})
+	}
+	return payload
+}
+
+func newDeliveryId() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+func logWebhookDelivery(delivery WebhookDelivery, config ConfigData) {
+	line, err := json.Marshal(delivery)
+	if err != nil {
+		return
+	}
+	log.Println("Webhook delivery", string(line))
+	if config.WebhookLogFile == "" {
+		return
+	}
+	fh, err := os.OpenFile(config.WebhookLogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		log.Println("Failed to open the webhook log", config.WebhookLogFile, "error:", err)
+		return
+	}
+	defer fh.Close()
+	fh.Write(append(line, '\n'))
+}
+
+// Sends one attempt, telling the caller whether it's worth trying again.
+func postWebhook(ctx context.Context, webhook WebhookConfig, deliveryId string, body []byte) (int, bool, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, false, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "MLBLG-Webhook/1")
+	request.Header.Set(webhookEventHeader, WebhookEventPublished)
+	request.Header.Set(webhookDeliveryHeader, deliveryId)
+	if webhook.Secret != "" {
+		request.Header.Set(webhookSignatureHeader, SignWebhook(webhook.Secret, body))
+	}
+	resp, err := webhookClient.Do(request)
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		// Leave the URL out, the delivery log already says where it went
+		err = urlErr.Err
+	}
+	if err != nil {
+		return 0, true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, false, nil
+	}
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return resp.StatusCode, retry, fmt.Errorf("receiver answered %s", resp.Status)
+}
+
+// DeliverWebhook posts the payload, retrying connection errors, 429s and
+// 5xx answers with exponential backoff until ctx's deadline. Every attempt
+// goes to the delivery log.
+func DeliverWebhook(ctx context.Context, webhook WebhookConfig, payload WebhookPayload, config ConfigData) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return deliverBody(ctx, webhook, webhook.URL, payload.Game.GamePk, body, config)
+}
+
+// logURL is what the delivery log shows for the receiver, which lets chat
+// webhooks keep the secret part of their URL out of it.
+func deliverBody(ctx context.Context, webhook WebhookConfig, logURL string, gamePk int, body []byte, config ConfigData) error {
+	deliveryId := newDeliveryId()
+	backoff := webhookFirstBackoff
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		status, retry, err := postWebhook(ctx, webhook, deliveryId, body)
+		delivery := WebhookDelivery{
+			Id:       deliveryId,
+			URL:      logURL,
+			GamePk:   gamePk,
+			Attempt:  attempt,
+			Status:   status,
+			Sent:     start.UTC().Format(time.RFC3339),
+			Duration: time.Since(start).Round(time.Millisecond).String(),
+		}
+		if err != nil {
+			delivery.Error = err.Error()
+		}
+		logWebhookDelivery(delivery, config)
+		if err == nil || !retry || attempt == webhookMaxAttempts {
+			return err
+		}
+		// Don't start a wait that ends past the deadline
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(backoff).After(deadline) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// NotifyPublished fires every configured webhook, chat post and email for a
+// newly published report. A failing receiver doesn't stop the others, and
+// all of them give up a little before ctx's deadline.
+func NotifyPublished(ctx context.Context, report ReportData, published []string, config ConfigData) error {
+	if len(published) == 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline.Add(-webhookDeadlineMargin))
+		defer cancel()
+	}
+	var deliveryErrors []error
+	if len(config.Webhooks) > 0 {
+		payload := BuildWebhookPayload(report, published, config)
+		for _, webhook := range config.Webhooks {
+			err := DeliverWebhook(ctx, webhook, payload, config)
+			if err != nil {
+				deliveryErrors = append(deliveryErrors, fmt.Errorf("webhook %s: %w", webhook.URL, err))
+			}
+		}
+	}
+	err := PostChatMessages(ctx, report, published, config)
+	if err != nil {
+		deliveryErrors = append(deliveryErrors, err)
+	}
+	err = EmailPublished(ctx, report, published, config)
+	if err != nil {
+		deliveryErrors = append(deliveryErrors, err)
+	}
+	return errors.Join(deliveryErrors...)
+}
@@ -0,0 +1,173 @@
+package pkg
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testWebhookSecret = "hunter2"
+
+type webhookAttempt struct {
+	Delivery  string
+	Event     string
+	Signature string
+	Body      []byte
+}
+
+// Answers with the given statuses in turn, repeating the last one, and
+// keeps every request it got.
+func newWebhookReceiver(t *testing.T, statuses ...int) (*httptest.Server, func() []webhookAttempt) {
+	var mutex sync.Mutex
+	var attempts []webhookAttempt
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		attempts = append(attempts, webhookAttempt{
+			Delivery:  r.Header.Get(webhookDeliveryHeader),
+			Event:     r.Header.Get(webhookEventHeader),
+			Signature: r.Header.Get(webhookSignatureHeader),
+			Body:      body,
+		})
+		status := statuses[min(len(attempts), len(statuses))-1]
+		mutex.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []webhookAttempt {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]webhookAttempt(nil), attempts...)
+	}
+}
+
+func testWebhookPayload() WebhookPayload {
+	return BuildWebhookPayload(testReport(776543, "Minnesota Twins", "Detroit Tigers"), []string{"page/a.pdf"}, ConfigData{})
+}
+
+func TestSignWebhook(t *testing.T) {
+	body := []byte(`{"event":"card.published"}`)
+	signature := SignWebhook(testWebhookSecret, body)
+	// What a receiver does to check it
+	mac := hmac.New(sha256.New, []byte(testWebhookSecret))
+	mac.Write(body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(signature), []byte(want)) {
+		t.Errorf("signature %q, want %q", signature, want)
+	}
+	if SignWebhook("other", body) == signature {
+		t.Error("a different secret gave the same signature")
+	}
+	if SignWebhook(testWebhookSecret, append(body, ' ')) == signature {
+		t.Error("a different body gave the same signature")
+	}
+}
+
+func TestDeliverWebhook(t *testing.T) {
+	server, attempts := newWebhookReceiver(t, http.StatusNoContent)
+	logFile := filepath.Join(t.TempDir(), "webhooks.log")
+	webhook := WebhookConfig{URL: server.URL, Secret: testWebhookSecret}
+	payload := testWebhookPayload()
+
+	err := DeliverWebhook(context.Background(), webhook, payload, ConfigData{WebhookLogFile: logFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := attempts()
+	if len(got) != 1 {
+		t.Fatalf("got %d attempts, want 1", len(got))
+	}
+	if got[0].Event != WebhookEventPublished {
+		t.Errorf("event header %q", got[0].Event)
+	}
+	if !hmac.Equal([]byte(got[0].Signature), []byte(SignWebhook(testWebhookSecret, got[0].Body))) {
+		t.Error("signature doesn't match the body the receiver got")
+	}
+	var received WebhookPayload
+	err = json.Unmarshal(got[0].Body, &received)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received.Game.GamePk != 776543 || received.Version != WebhookPayloadVersion {
+		t.Errorf("received %+v", received)
+	}
+
+	logData, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var delivery WebhookDelivery
+	err = json.Unmarshal(logData, &delivery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivery.Id != got[0].Delivery || delivery.Status != http.StatusNoContent || delivery.Attempt != 1 {
+		t.Errorf("logged %+v", delivery)
+	}
+}
+
+func TestDeliverWebhookRetries(t *testing.T) {
+	for _, status := range []int{http.StatusInternalServerError, http.StatusTooManyRequests} {
+		server, attempts := newWebhookReceiver(t, status, http.StatusOK)
+		webhook := WebhookConfig{URL: server.URL, Secret: testWebhookSecret}
+		err := DeliverWebhook(context.Background(), webhook, testWebhookPayload(), ConfigData{})
+		if err != nil {
+			t.Errorf("%d then 200: %v", status, err)
+			continue
+		}
+		got := attempts()
+		if len(got) != 2 {
+			t.Errorf("%d then 200: got %d attempts, want 2", status, len(got))
+			continue
+		}
+		if got[0].Delivery == "" || got[0].Delivery != got[1].Delivery {
+			t.Errorf("delivery id changed between attempts: %q, %q", got[0].Delivery, got[1].Delivery)
+		}
+		if got[0].Signature != got[1].Signature {
+			t.Error("signature changed between attempts")
+		}
+	}
+}
+
+func TestDeliverWebhookNoRetryOnClientError(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound} {
+		server, attempts := newWebhookReceiver(t, status, http.StatusOK)
+		err := DeliverWebhook(context.Background(), WebhookConfig{URL: server.URL}, testWebhookPayload(), ConfigData{})
+		if err == nil {
+			t.Errorf("%d: delivery succeeded", status)
+		}
+		if got := attempts(); len(got) != 1 {
+			t.Errorf("%d: got %d attempts, want 1", status, len(got))
+		}
+		if got := attempts(); len(got) > 0 && got[0].Signature != "" {
+			t.Errorf("%d: signed without a secret", status)
+		}
+	}
+}
+
+func TestDeliverWebhookStopsAtDeadline(t *testing.T) {
+	server, attempts := newWebhookReceiver(t, http.StatusServiceUnavailable)
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := DeliverWebhook(ctx, WebhookConfig{URL: server.URL}, testWebhookPayload(), ConfigData{})
+	if err == nil {
+		t.Fatal("delivery to a failing receiver succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Errorf("waited %s for a backoff past the deadline", elapsed)
+	}
+	if got := attempts(); len(got) != 1 {
+		t.Errorf("got %d attempts, want 1", len(got))
+	}
+}